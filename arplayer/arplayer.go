@@ -0,0 +1,99 @@
+// Package arplayer adapts package arp's Packet type for use with gopacket,
+// so it can be used in a gopacket.DecodingLayerParser.  It is a separate
+// module so that users of package arp do not depend on gopacket.
+package arplayer
+
+import (
+	"io"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/mdlayher/arp"
+)
+
+var (
+	_ gopacket.DecodingLayer     = &Layer{}
+	_ gopacket.SerializableLayer = &Layer{}
+)
+
+// A Layer is an ARP packet which implements gopacket.Layer,
+// gopacket.DecodingLayer, and gopacket.SerializableLayer.  It decodes ARP
+// packets using the embedded arp.Packet's UnmarshalBinary method.
+//
+// The embedded Packet's addresses are copied from the decoded data, but
+// LayerContents and LayerPayload reference the data directly, so they are
+// only valid until that data is modified.
+type Layer struct {
+	arp.Packet
+
+	contents []byte
+	payload  []byte
+}
+
+// LayerType returns layers.LayerTypeARP.
+func (l *Layer) LayerType() gopacket.LayerType {
+	return layers.LayerTypeARP
+}
+
+// LayerContents returns the bytes of the ARP packet.
+func (l *Layer) LayerContents() []byte {
+	return l.contents
+}
+
+// LayerPayload returns any bytes following the ARP packet, such as ethernet
+// padding.
+func (l *Layer) LayerPayload() []byte {
+	return l.payload
+}
+
+// CanDecode returns layers.LayerTypeARP.
+func (l *Layer) CanDecode() gopacket.LayerClass {
+	return layers.LayerTypeARP
+}
+
+// NextLayerType returns gopacket.LayerTypePayload, as ARP carries no further
+// layers.
+func (l *Layer) NextLayerType() gopacket.LayerType {
+	return gopacket.LayerTypePayload
+}
+
+// DecodeFromBytes decodes an ARP packet from data.  If data is too short to
+// contain the packet, df is notified that the packet is truncated.
+func (l *Layer) DecodeFromBytes(data []byte, df gopacket.DecodeFeedback) error {
+	if err := l.Packet.UnmarshalBinary(data); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			df.SetTruncated()
+		}
+		return err
+	}
+
+	n := l.length()
+	l.contents = data[:n]
+	l.payload = data[n:]
+
+	return nil
+}
+
+// SerializeTo prepends the binary form of the ARP packet to b, using the
+// embedded arp.Packet's MarshalTo method.  If opts.FixLengths is set, the
+// packet's address lengths are first set from its sender addresses.
+func (l *Layer) SerializeTo(b gopacket.SerializeBuffer, opts gopacket.SerializeOptions) error {
+	if opts.FixLengths {
+		l.HardwareAddrLength = uint8(len(l.SenderHardwareAddr))
+		l.IPLength = uint8(l.SenderIP.BitLen() / 8)
+	}
+
+	bb, err := b.PrependBytes(l.length())
+	if err != nil {
+		return err
+	}
+
+	_, err = l.Packet.MarshalTo(bb)
+	return err
+}
+
+// length returns the length of the ARP packet, using the address lengths
+// declared in its header.
+func (l *Layer) length() int {
+	return 8 + 2*int(l.HardwareAddrLength) + 2*int(l.IPLength)
+}
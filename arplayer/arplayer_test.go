@@ -0,0 +1,131 @@
+package arplayer
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+)
+
+func TestLayerDecodingLayerParser(t *testing.T) {
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	p, err := arp.NewPacket(arp.OperationRequest, mac, netip.MustParseAddr("192.168.1.1"),
+		ethernet.Broadcast, netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pad the frame to the minimum ethernet frame length.
+	padding := make([]byte, 60-14-len(pb))
+	fb, err := (&ethernet.Frame{
+		Destination: ethernet.Broadcast,
+		Source:      mac,
+		EtherType:   ethernet.EtherTypeARP,
+		Payload:     append(pb, padding...),
+	}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		eth layers.Ethernet
+		l   Layer
+	)
+	parser := gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &eth, &l)
+	parser.IgnoreUnsupported = true
+
+	var decoded []gopacket.LayerType
+	if err := parser.DecodeLayers(fb, &decoded); err != nil {
+		t.Fatalf("failed to decode layers: %v", err)
+	}
+
+	want := []gopacket.LayerType{layers.LayerTypeEthernet, layers.LayerTypeARP}
+	if got := decoded; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected layers:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if !p.Equal(&l.Packet) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", p, &l.Packet)
+	}
+	if want, got := pb, l.LayerContents(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected layer contents:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := padding, l.LayerPayload(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected layer payload:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestLayerDecodeFromBytesTruncated(t *testing.T) {
+	var l Layer
+	df := &feedback{}
+
+	if err := l.DecodeFromBytes([]byte{0x00, 0x01, 0x08, 0x00}, df); err == nil {
+		t.Fatal("expected an error, but none occurred")
+	}
+	if !df.truncated {
+		t.Fatal("expected packet to be marked truncated")
+	}
+}
+
+// feedback is a gopacket.DecodeFeedback which records truncation.
+type feedback struct {
+	truncated bool
+}
+
+func (f *feedback) SetTruncated() { f.truncated = true }
+
+func TestLayerSerializeTo(t *testing.T) {
+	mac := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	p, err := arp.NewPacket(arp.OperationReply, mac, netip.MustParseAddr("192.168.1.1"),
+		mac, netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Zero the lengths so FixLengths must restore them.
+	l := &Layer{Packet: *p}
+	l.HardwareAddrLength = 0
+	l.IPLength = 0
+
+	buf := gopacket.NewSerializeBuffer()
+	err = gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true},
+		&layers.Ethernet{
+			SrcMAC:       mac,
+			DstMAC:       mac,
+			EthernetType: layers.EthernetTypeARP,
+		},
+		l,
+	)
+	if err != nil {
+		t.Fatalf("failed to serialize layers: %v", err)
+	}
+
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The ARP packet follows the 14 byte ethernet header.
+	b := buf.Bytes()
+	if want, got := pb, b[14:14+len(pb)]; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected ARP packet bytes:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	var got arp.Packet
+	if err := got.UnmarshalBinary(b[14:]); err != nil {
+		t.Fatalf("failed to unmarshal packet: %v", err)
+	}
+	if !p.Equal(&got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", p, &got)
+	}
+}
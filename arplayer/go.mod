@@ -0,0 +1,9 @@
+module github.com/mdlayher/arp/arplayer
+
+go 1.12
+
+require (
+	github.com/google/gopacket v1.1.19
+	github.com/mdlayher/arp v0.0.0-20261015234125-1b44be77538c
+	github.com/mdlayher/ethernet v0.0.0-20220221185849-529eae5b6118
+)
@@ -0,0 +1,134 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheTTL is the time-to-live of entries added by
+	// Client.ResolveCached to a zero value Cache.
+	defaultCacheTTL = time.Minute
+
+	// cacheSweepInterval is the minimum interval between removals of all
+	// expired entries from a Cache.
+	cacheSweepInterval = time.Minute
+)
+
+// A Cache is an in-memory cache of IPv4 to hardware address bindings, whose
+// entries expire after a time-to-live.  A Cache is safe for concurrent use.
+// The zero value is an empty Cache whose entries added by
+// Client.ResolveCached expire after one minute.
+//
+// A Cache may be assigned to the Cache field of a Client, so
+// Client.ResolveCached can avoid sending ARP requests for recently resolved
+// addresses.
+type Cache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[netip.Addr]cacheEntry
+	nextSweep time.Time
+}
+
+// A cacheEntry is a hardware address and the time at which it expires.
+type cacheEntry struct {
+	mac     net.HardwareAddr
+	expires time.Time
+}
+
+// NewCache creates an empty Cache.  ttl is the time-to-live of entries added
+// by Client.ResolveCached.  If ttl is zero or negative, one minute is used.
+func NewCache(ttl time.Duration) *Cache {
+	return &Cache{ttl: ttl}
+}
+
+// Get returns the hardware address cached for ip, and reports whether an
+// unexpired entry was found.  Expired entries are removed.  The returned
+// hardware address is a copy, so callers may modify it.
+func (c *Cache) Get(ip netip.Addr) (net.HardwareAddr, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[ip]
+	if !ok {
+		return nil, false
+	}
+
+	if !c.timeNow().Before(e.expires) {
+		delete(c.entries, ip)
+		return nil, false
+	}
+
+	return append(net.HardwareAddr(nil), e.mac...), true
+}
+
+// Set caches mac as the hardware address for ip, until ttl elapses.  Any
+// existing entry for ip is replaced.
+func (c *Cache) Set(ip netip.Addr, mac net.HardwareAddr, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.timeNow()
+	if c.entries == nil {
+		c.entries = make(map[netip.Addr]cacheEntry)
+	}
+
+	// Periodically remove expired entries which have not been read, so the
+	// Cache does not grow without bound.
+	if !now.Before(c.nextSweep) {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextSweep = now.Add(cacheSweepInterval)
+	}
+
+	c.entries[ip] = cacheEntry{
+		mac:     append(net.HardwareAddr(nil), mac...),
+		expires: now.Add(ttl),
+	}
+}
+
+// defaultTTL returns the time-to-live of entries added by
+// Client.ResolveCached.
+func (c *Cache) defaultTTL() time.Duration {
+	if c.ttl > 0 {
+		return c.ttl
+	}
+	return defaultCacheTTL
+}
+
+// timeNow returns the current time, using the Cache's clock if one is set.
+func (c *Cache) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// ResolveCached returns the hardware address for ip from the Client's Cache
+// if present.  Otherwise, it performs an ARP request in the same way as
+// Resolve, and adds the reply to the Cache.  If the Client has no Cache,
+// ResolveCached is equivalent to Resolve.
+func (c *Client) ResolveCached(ip netip.Addr) (net.HardwareAddr, error) {
+	if c.Cache == nil {
+		return c.Resolve(ip)
+	}
+
+	if mac, ok := c.Cache.Get(ip); ok {
+		return mac, nil
+	}
+
+	mac, err := c.Resolve(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Cache.Set(ip, mac, c.Cache.defaultTTL())
+	return mac, nil
+}
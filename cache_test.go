@@ -0,0 +1,146 @@
+package arp
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	now := time.Unix(0, 0)
+	c := NewCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	if _, ok := c.Get(ip); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+
+	c.Set(ip, mac, 10*time.Second)
+
+	now = now.Add(9 * time.Second)
+	got, ok := c.Get(ip)
+	if !ok {
+		t.Fatal("entry expired early")
+	}
+	if want := mac; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := c.Get(ip); ok {
+		t.Fatal("entry did not expire")
+	}
+	if want, got := 0, len(c.entries); want != got {
+		t.Fatalf("expired entry was not removed: %d entries", got)
+	}
+}
+
+func TestCacheZeroValue(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	var c Cache
+	if _, ok := c.Get(ip); ok {
+		t.Fatal("empty cache returned an entry")
+	}
+
+	c.Set(ip, mac, time.Minute)
+
+	got, ok := c.Get(ip)
+	if !ok {
+		t.Fatal("entry not found")
+	}
+	if want := mac; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := defaultCacheTTL, c.defaultTTL(); want != got {
+		t.Fatalf("unexpected default TTL: %v != %v", want, got)
+	}
+}
+
+func TestCacheSetSweep(t *testing.T) {
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	now := time.Unix(0, 0)
+	c := NewCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set(netip.MustParseAddr("192.168.1.10"), mac, time.Second)
+
+	// Expired entries are kept until the sweep interval elapses.
+	now = now.Add(2 * time.Second)
+	c.Set(netip.MustParseAddr("192.168.1.11"), mac, time.Hour)
+	if want, got := 2, len(c.entries); want != got {
+		t.Fatalf("unexpected number of entries before sweep: %d != %d", want, got)
+	}
+
+	now = now.Add(cacheSweepInterval)
+	c.Set(netip.MustParseAddr("192.168.1.12"), mac, time.Hour)
+	if want, got := 2, len(c.entries); want != got {
+		t.Fatalf("unexpected number of entries after sweep: %d != %d", want, got)
+	}
+	if _, ok := c.entries[netip.MustParseAddr("192.168.1.10")]; ok {
+		t.Fatal("expired entry was not swept")
+	}
+}
+
+func TestCacheGetCopy(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	c := NewCache(time.Minute)
+	c.Set(ip, mac, time.Minute)
+
+	got, ok := c.Get(ip)
+	if !ok {
+		t.Fatal("entry not found")
+	}
+
+	// Modifying the returned address must not affect the cached entry.
+	got[0] = 0x00
+
+	got, ok = c.Get(ip)
+	if !ok {
+		t.Fatal("entry not found")
+	}
+	if want := mac; !bytes.Equal(want, got) {
+		t.Fatalf("cached entry was modified:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientResolveCached(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	p := newReplyPacketConn(func(_ []byte) []timedFrame {
+		return []timedFrame{{b: replyFrame(t, mac, ip)}}
+	})
+	c := testClient(t, p)
+
+	now := time.Unix(0, 0)
+	c.Cache = NewCache(time.Minute)
+	c.Cache.now = func() time.Time { return now }
+
+	// The first two lookups should only send a single request, and the
+	// third should send another after the entry expires.
+	for i, d := range []time.Duration{0, 30 * time.Second, 31 * time.Second} {
+		now = now.Add(d)
+
+		got, err := c.ResolveCached(ip)
+		if err != nil {
+			t.Fatalf("[%02d] unexpected error: %v", i, err)
+		}
+		if want := mac; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] unexpected hardware address:\n- want: %v\n-  got: %v", i, want, got)
+		}
+	}
+
+	if want, got := 2, len(p.writes); want != got {
+		t.Fatalf("unexpected number of requests: %d != %d", want, got)
+	}
+}
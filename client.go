@@ -1,7 +1,10 @@
 package arp
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"math"
 	"net"
 	"net/netip"
 	"time"
@@ -14,6 +17,30 @@ import (
 // address.
 var errNoIPv4Addr = errors.New("no IPv4 address available for interface")
 
+// ErrRequestTimeout is returned when an ARP request is not answered within
+// the Client's DefaultTimeout.
+var ErrRequestTimeout = errors.New("ARP request timed out")
+
+// ErrNoReply is returned by ResolveRetry when no reply arrives after every
+// attempt.
+var ErrNoReply = errors.New("no ARP reply received")
+
+// frameOverhead is the length of an ethernet header with an 802.1Q VLAN tag,
+// which is added to the MTU when sizing read buffers.
+const frameOverhead = 14 + 4
+
+// maxFrameLength is the length of the largest possible ethernet frame
+// carrying an ARP packet: an 802.1Q VLAN tagged ethernet header, followed by
+// an 8 byte ARP header and two pairs of 255 byte addresses.
+const maxFrameLength = frameOverhead + 8 + 4*255
+
+// defaultTimeout is the DefaultTimeout used by a Client when none is set.
+const defaultTimeout = 5 * time.Second
+
+// contextPollInterval is the maximum amount of time a read may block before
+// checking whether its context.Context has been canceled.
+const contextPollInterval = 100 * time.Millisecond
+
 // protocolARP is the uint16 EtherType representation of ARP (Address
 // Resolution Protocol, RFC 826).
 const protocolARP = 0x0806
@@ -21,64 +48,283 @@ const protocolARP = 0x0806
 // A Client is an ARP client, which can be used to send and receive
 // ARP packets.
 type Client struct {
-	ifi *net.Interface
-	ip  netip.Addr
-	p   net.PacketConn
+	// SourcePolicy, if set, chooses the sender IPv4 address for each ARP
+	// request sent by the Client, given the IPv4 addresses of the Client's
+	// interface with their prefix lengths, and the target IPv4 address of
+	// the request.  This can be used to implement policies such as longest
+	// prefix match.  candidates is a copy, so SourcePolicy may modify it.
+	//
+	// If nil, the first IPv4 address of the interface is always used.
+	SourcePolicy func(candidates []netip.Prefix, target netip.Addr) netip.Addr
+
+	// DefaultTimeout is the maximum amount of time Resolve and ResolveReply
+	// wait for a reply when no read deadline has been set on the Client,
+	// after which ErrRequestTimeout is returned.  This prevents blocking
+	// forever when replies never arrive, or unrelated packets continuously
+	// arrive instead.
+	//
+	// If zero, a default of 5 seconds is used.  If negative, Resolve waits
+	// indefinitely.
+	DefaultTimeout time.Duration
+
+	// Cache, if set, is consulted by ResolveCached before sending an ARP
+	// request, and is populated from replies.
+	Cache *Cache
+
+	// ReadBufferSize is the size of the buffer used to read each frame from
+	// the Client's raw socket.  Frames larger than the buffer are truncated,
+	// which can cause packets with long hardware addresses, such as those of
+	// IP over InfiniBand, to fail to parse.
+	//
+	// If zero or negative, the buffer is sized to hold the largest possible
+	// ARP frame, or a full frame at the MTU of the Client's interface if
+	// that is smaller.  A larger buffer is never needed, as only padding
+	// follows the ARP packet.
+	ReadBufferSize int
+
+	ifi    *net.Interface
+	ip     netip.Addr
+	addrs  []netip.Prefix
+	p      net.PacketConn
+	hwType HardwareType
+
+	// readDeadline is the read deadline last set by the caller, used to
+	// determine whether DefaultTimeout applies.
+	readDeadline time.Time
+
+	// rp is the Packet reused by ReceiveInto.
+	rp Packet
 }
 
 // Dial creates a new Client using the specified network interface.
 // Dial retrieves the IPv4 address of the interface and binds a raw socket
 // to send and receive ARP packets.
+//
+// Dial attaches a BPF filter to the raw socket so the kernel drops frames
+// other than ARP.  To dial without the filter, or with a custom filter, use
+// DialConfig.
 func Dial(ifi *net.Interface) (*Client, error) {
+	return DialConfig(ifi, nil)
+}
+
+// DialConfig creates a new Client in the same way as Dial, but passes cfg
+// to the underlying raw socket, so callers may tune options such as its BPF
+// filter.
+//
+// If cfg is nil, the default configuration used by Dial is used, which
+// attaches a BPF filter so the kernel drops frames other than ARP without
+// waking the process.  Any non-nil cfg replaces the default configuration
+// entirely, so to disable the filter, pass an empty &packet.Config{}.
+func DialConfig(ifi *net.Interface, cfg *packet.Config) (*Client, error) {
+	cfg, err := configOrDefault(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return dialConfig(ifi, cfg, listenPacket)
+}
+
+// listenPacket opens a raw socket which sends and receives ARP packets on
+// ifi.
+func listenPacket(ifi *net.Interface, cfg *packet.Config) (net.PacketConn, error) {
+	return packet.Listen(ifi, packet.Raw, protocolARP, cfg)
+}
+
+// dialConfig is the internal implementation of DialConfig.  It is used to
+// allow an arbitrary listen function to be used, so testing is easier to
+// accomplish.
+func dialConfig(ifi *net.Interface, cfg *packet.Config, listen func(ifi *net.Interface, cfg *packet.Config) (net.PacketConn, error)) (*Client, error) {
 	// Open raw socket to send and receive ARP packets using ethernet frames
 	// we build ourselves.
-	p, err := packet.Listen(ifi, packet.Raw, protocolARP, nil)
+	p, err := listen(ifi, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := New(ifi, p)
 	if err != nil {
+		_ = p.Close()
 		return nil, err
 	}
-	return New(ifi, p)
+
+	return c, nil
+}
+
+// DialRetry creates a new Client using the specified network interface, in
+// the same way as Dial.  If creating the Client fails, DialRetry waits for
+// delay and tries again, up to a total of attempts times.  This smooths over
+// transient failures which may occur when an interface has just come up.
+// An interface which has not yet been assigned an IPv4 address is also
+// treated as a failed attempt.  If attempts is less than 1, a single attempt
+// is made.
+//
+// If every attempt fails, the error from the last attempt is returned.  If
+// ctx is canceled first, ctx.Err() is returned.
+func DialRetry(ctx context.Context, ifi *net.Interface, attempts int, delay time.Duration) (*Client, error) {
+	cfg, err := configOrDefault(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return dialRetry(ctx, ifi, cfg, attempts, delay, listenPacket)
+}
+
+// dialRetry is the internal implementation of DialRetry.  It is used to
+// allow an arbitrary listen function to be used by each attempt, so testing
+// is easier to accomplish.
+func dialRetry(ctx context.Context, ifi *net.Interface, cfg *packet.Config, attempts int, delay time.Duration, listen func(ifi *net.Interface, cfg *packet.Config) (net.PacketConn, error)) (*Client, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		var c *Client
+		c, err = dialConfig(ifi, cfg, listen)
+		if err != nil {
+			continue
+		}
+
+		if !c.ip.IsValid() {
+			_ = c.Close()
+			err = errNoIPv4Addr
+			continue
+		}
+
+		return c, nil
+	}
+
+	return nil, err
 }
 
 // New creates a new Client using the specified network interface
 // and net.PacketConn. This allows the caller to define exactly how they bind to the
 // net.PacketConn. This is most useful to define what protocol to pass to socket(7).
 //
+// The interface need not have an IPv4 address: such a Client can probe for
+// and claim an address, but its requests return an error until one is
+// assigned and Refresh is called.
+//
 // In most cases, callers would be better off calling Dial.
 func New(ifi *net.Interface, p net.PacketConn) (*Client, error) {
-	// Check for usable IPv4 addresses for the Client
+	addrs, err := interfaceAddrs(ifi)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(ifi, p, addrs)
+}
+
+// NewClientWithConn creates a new Client using the specified network
+// interface and net.PacketConn, which uses ip as the sender IPv4 address of
+// its requests.  Unlike New, the addresses of the interface are not looked
+// up, so any transport may be used, such as a userspace network stack or a
+// mock net.PacketConn in tests.
+//
+// If ip is not an IPv4 address, ErrInvalidIP is returned.
+func NewClientWithConn(ifi *net.Interface, p net.PacketConn, ip netip.Addr) (*Client, error) {
+	if !ip.Is4() {
+		return nil, ErrInvalidIP
+	}
+
+	return newClient(ifi, p, []netip.Prefix{netip.PrefixFrom(ip, ip.BitLen())})
+}
+
+// interfaceAddrs retrieves the IP addresses assigned to ifi, along with the
+// prefix lengths of their networks.
+func interfaceAddrs(ifi *net.Interface) ([]netip.Prefix, error) {
 	addrs, err := ifi.Addrs()
 	if err != nil {
 		return nil, err
 	}
 
-	ipaddrs := make([]netip.Addr, len(addrs))
+	prefixes := make([]netip.Prefix, len(addrs))
 	for i, a := range addrs {
 		ipPrefix, err := netip.ParsePrefix(a.String())
 		if err != nil {
 			return nil, err
 		}
-		ipaddrs[i] = ipPrefix.Addr()
+		prefixes[i] = ipPrefix
 	}
 
-	return newClient(ifi, p, ipaddrs)
+	return prefixes, nil
 }
 
 // newClient is the internal, generic implementation of newClient.  It is used
 // to allow an arbitrary net.PacketConn to be used in a Client, so testing
 // is easier to accomplish.
-func newClient(ifi *net.Interface, p net.PacketConn, addrs []netip.Addr) (*Client, error) {
-	ip, err := firstIPv4Addr(addrs)
-	if err != nil {
-		return nil, err
+func newClient(ifi *net.Interface, p net.PacketConn, addrs []netip.Prefix) (*Client, error) {
+	// An interface without an IPv4 address is still usable for probes and
+	// link-local address configuration, so errNoIPv4Addr is deferred until
+	// the Client needs a sender IPv4 address.
+	ip, _ := firstIPv4Addr(addrs)
+
+	// Keep all IPv4 addresses as candidates for SourcePolicy.
+	var ip4s []netip.Prefix
+	for _, a := range addrs {
+		if a.Addr().Is4() {
+			ip4s = append(ip4s, a)
+		}
+	}
+
+	hwType := HardwareTypeEthernet
+	if ifi != nil {
+		hwType = InterfaceHardwareType(ifi)
 	}
 
 	return &Client{
-		ifi: ifi,
-		ip:  ip,
-		p:   p,
+		ifi:    ifi,
+		ip:     ip,
+		addrs:  ip4s,
+		p:      p,
+		hwType: hwType,
 	}, nil
 }
 
+// Refresh re-reads the hardware and IPv4 addresses of the Client's network
+// interface, so that a long-lived Client uses the interface's current
+// addresses for future requests.  Daemons typically call Refresh when they
+// are notified that the interface's addresses have changed.
+//
+// Refresh must not be called concurrently with other Client methods.
+func (c *Client) Refresh() error {
+	ifi, err := net.InterfaceByIndex(c.ifi.Index)
+	if err != nil {
+		return err
+	}
+
+	addrs, err := interfaceAddrs(ifi)
+	if err != nil {
+		return err
+	}
+
+	return c.refresh(ifi, addrs)
+}
+
+// refresh is the internal, generic implementation of Refresh.  It is used
+// to allow arbitrary interface addresses to be applied to a Client, so
+// testing is easier to accomplish.
+func (c *Client) refresh(ifi *net.Interface, addrs []netip.Prefix) error {
+	nc, err := newClient(ifi, c.p, addrs)
+	if err != nil {
+		return err
+	}
+
+	c.ifi = nc.ifi
+	c.ip = nc.ip
+	c.addrs = nc.addrs
+	c.hwType = nc.hwType
+	return nil
+}
+
 // Close closes the Client's raw socket and stops sending and receiving
 // ARP packets.
 func (c *Client) Close() error {
@@ -93,17 +339,39 @@ func (c *Client) Close() error {
 // hardware address, Request allows sending many requests in a row,
 // retrieving the responses afterwards.
 func (c *Client) Request(ip netip.Addr) error {
-	if !c.ip.IsValid() {
-		return errNoIPv4Addr
+	arp, err := c.buildRequest(ip)
+	if err != nil {
+		return err
+	}
+	return c.WriteTo(arp, ethernet.Broadcast)
+}
+
+// buildRequest builds an ARP request packet for the broadcast address,
+// which asks for the hardware address associated with ip.
+func (c *Client) buildRequest(ip netip.Addr) (*Packet, error) {
+	src := c.ip
+	if c.SourcePolicy != nil {
+		src = c.SourcePolicy(append([]netip.Prefix(nil), c.addrs...), ip)
+	}
+	if !src.IsValid() {
+		return nil, errNoIPv4Addr
 	}
 
 	// Create ARP packet for broadcast address to attempt to find the
 	// hardware address of the input IP address
-	arp, err := NewPacket(OperationRequest, c.ifi.HardwareAddr, c.ip, ethernet.Broadcast, ip)
+	return c.newPacket(OperationRequest, c.ifi.HardwareAddr, src, ethernet.Broadcast, ip)
+}
+
+// newPacket creates a Packet in the same way as NewPacket, but with the
+// hardware type of the Client's interface.
+func (c *Client) newPacket(op Operation, srcHW net.HardwareAddr, srcIP netip.Addr, dstHW net.HardwareAddr, dstIP netip.Addr) (*Packet, error) {
+	p, err := NewPacket(op, srcHW, srcIP, dstHW, dstIP)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return c.WriteTo(arp, ethernet.Broadcast)
+
+	p.HardwareType = c.hwType
+	return p, nil
 }
 
 // Resolve performs an ARP request, attempting to retrieve the
@@ -111,31 +379,403 @@ func (c *Client) Request(ip netip.Addr) error {
 // be used concurrently with Read. If you're using Read (usually in a
 // loop), you need to use Request instead. Resolve may read more than
 // one message if it receives messages unrelated to the request.
+//
+// If no read deadline has been set on the Client, Resolve returns
+// ErrRequestTimeout once the Client's DefaultTimeout elapses without a
+// reply.
+//
+// Resolve is equivalent to ResolveContext with context.Background().
 func (c *Client) Resolve(ip netip.Addr) (net.HardwareAddr, error) {
-	err := c.Request(ip)
+	return c.ResolveContext(context.Background(), ip)
+}
+
+// ResolveContext performs an ARP request in the same way as Resolve, but
+// returns ctx.Err() if ctx is canceled or its deadline is exceeded before a
+// reply arrives.  The deadline of ctx is applied to reads from the Client's
+// raw socket, which is polled periodically to check for cancelation.
+//
+// If ctx can be canceled, it alone bounds the request, and the Client's
+// DefaultTimeout does not apply.  ResolveContext then overrides any read
+// deadline set on the Client, and clears it before returning.
+func (c *Client) ResolveContext(ctx context.Context, ip netip.Addr) (net.HardwareAddr, error) {
+	arp, _, err := c.resolveContext(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	return arp.SenderHardwareAddr, nil
+}
+
+// Verify performs an ARP request in the same way as Resolve, and reports
+// whether the hardware address which replies on behalf of ip is expected.
+// The actual hardware address is also returned.  Periodically verifying the
+// bindings of critical machines, such as a gateway, can detect stale ARP
+// cache entries or ARP spoofing.
+func (c *Client) Verify(ip netip.Addr, expected net.HardwareAddr) (bool, net.HardwareAddr, error) {
+	mac, err := c.Resolve(ip)
+	if err != nil {
+		return false, nil, err
+	}
+
+	return bytes.Equal(mac, expected), mac, nil
+}
+
+// ResolveTimed performs an ARP request in the same way as Resolve, and also
+// returns the round-trip time from sending the request to reading the
+// matching reply, as reported by arping-style tools.
+func (c *Client) ResolveTimed(ip netip.Addr) (net.HardwareAddr, time.Duration, error) {
+	start := time.Now()
+	if err := c.Request(ip); err != nil {
+		return nil, 0, err
+	}
+
+	arp, _, err := c.awaitReply(ip)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return arp.SenderHardwareAddr, time.Since(start), nil
+}
+
+// A Reply is an ARP reply matched by ResolveReply, together with details
+// about how it was delivered.
+type Reply struct {
+	// Packet is the matched ARP reply.
+	Packet *Packet
+
+	// Broadcast reports whether the ethernet frame carrying the reply was
+	// sent to the broadcast address, rather than to the Client's hardware
+	// address.  Replies are normally unicast, so broadcast replies may
+	// indicate a misbehaving host or switch.
+	Broadcast bool
+}
+
+// ResolveReply performs an ARP request in the same way as Resolve, but
+// returns the matched reply along with details about how it was delivered.
+func (c *Client) ResolveReply(ip netip.Addr) (*Reply, error) {
+	arp, eth, err := c.resolveContext(context.Background(), ip)
 	if err != nil {
 		return nil, err
 	}
 
+	return &Reply{
+		Packet:    arp,
+		Broadcast: bytes.Equal(eth.Destination, ethernet.Broadcast),
+	}, nil
+}
+
+// resolveContext performs an ARP request for ip, and returns the first
+// matching reply, together with its ethernet frame, or ctx.Err() if ctx is
+// canceled first.
+func (c *Client) resolveContext(ctx context.Context, ip netip.Addr) (*Packet, *ethernet.Frame, error) {
+	if err := c.Request(ip); err != nil {
+		return nil, nil, err
+	}
+
+	// A context which can never be canceled doesn't need to be polled, so
+	// rely on the read deadline and DefaultTimeout instead.
+	if ctx.Done() == nil {
+		return c.awaitReply(ip)
+	}
+
+	for {
+		arp, eth, err := c.readContext(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isReplyFor(arp, ip) {
+			return arp, eth, nil
+		}
+	}
+}
+
+// awaitReply reads until a reply for ip arrives, and returns it together with
+// its ethernet frame.  If no read deadline is set, awaitReply gives up with
+// ErrRequestTimeout after the Client's DefaultTimeout.
+func (c *Client) awaitReply(ip netip.Addr) (*Packet, *ethernet.Frame, error) {
+	var deadline time.Time
+	if d := c.defaultTimeout(); d > 0 && c.readDeadline.IsZero() {
+		deadline = time.Now().Add(d)
+		if err := c.p.SetReadDeadline(deadline); err != nil {
+			return nil, nil, err
+		}
+		defer c.p.SetReadDeadline(time.Time{})
+	}
+
 	// Loop and wait for replies
+	for {
+		arp, eth, err := c.Read()
+		if err != nil {
+			if !deadline.IsZero() && isTimeout(err) {
+				return nil, nil, ErrRequestTimeout
+			}
+			return nil, nil, err
+		}
+
+		if isReplyFor(arp, ip) {
+			return arp, eth, nil
+		}
+
+		// Don't rely on the net.PacketConn to enforce the deadline if
+		// unrelated packets keep arriving.
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, nil, ErrRequestTimeout
+		}
+	}
+}
+
+// defaultTimeout returns the DefaultTimeout for c, or a negative duration if
+// resolution should wait indefinitely.
+func (c *Client) defaultTimeout() time.Duration {
+	if c.DefaultTimeout == 0 {
+		return defaultTimeout
+	}
+	return c.DefaultTimeout
+}
+
+// ResolveAllTimeout performs an ARP request and collects the hardware
+// addresses of every machine which replies on behalf of an IPv4 address
+// within the duration d.  This is useful for detecting multiple machines
+// which claim the same IPv4 address.
+//
+// Once d has elapsed, the hardware addresses seen are returned with a nil
+// error, and are the complete set of replies.  A non-nil error is only
+// returned if sending the request or reading replies fails, in which case
+// the hardware addresses seen before the failure are also returned.
+//
+// ResolveAllTimeout overrides any read deadline set on the Client, and
+// clears it before returning.
+func (c *Client) ResolveAllTimeout(ip netip.Addr, d time.Duration) ([]net.HardwareAddr, error) {
+	if err := c.Request(ip); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(d)
+	if err := c.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	defer c.SetReadDeadline(time.Time{})
+
+	var macs []net.HardwareAddr
 	for {
 		arp, _, err := c.Read()
 		if err != nil {
-			return nil, err
+			// The listening window ending is the expected way to finish.
+			if isTimeout(err) {
+				return macs, nil
+			}
+			return macs, err
+		}
+
+		if isReplyFor(arp, ip) && !containsHardwareAddr(macs, arp.SenderHardwareAddr) {
+			macs = append(macs, arp.SenderHardwareAddr)
+		}
+
+		// Don't rely on the net.PacketConn to enforce the deadline if
+		// unrelated packets keep arriving.
+		if time.Now().After(deadline) {
+			return macs, nil
+		}
+	}
+}
+
+// A Result is the outcome of resolving a single IPv4 address with
+// ResolveMany.
+type Result struct {
+	// IP is the IPv4 address which was resolved.
+	IP netip.Addr
+
+	// HardwareAddr is the hardware address which replied on behalf of IP,
+	// if Err is nil.
+	HardwareAddr net.HardwareAddr
+
+	// Err is ErrRequestTimeout if no reply arrived, or the error which
+	// occurred while sending the request for IP.
+	Err error
+}
+
+// ResolveMany sends an ARP request for each IPv4 address in ips, and then
+// reads replies until every address is resolved or the duration d elapses.
+// Unlike calling Resolve for each address in turn, unreachable addresses
+// only cost a single timeout in total.
+//
+// The returned Results are in the same order as ips.  If reading replies
+// fails for a reason other than a timeout, that error is reported for every
+// unresolved address.
+//
+// ResolveMany overrides any read deadline set on the Client, and clears it
+// before returning.
+func (c *Client) ResolveMany(ips []netip.Addr, d time.Duration) []Result {
+	results := make([]Result, len(ips))
+	pending := make(map[netip.Addr][]int, len(ips))
+	for i, ip := range ips {
+		results[i].IP = ip
+
+		// Only request each address once, even if it is repeated.
+		if idx, ok := pending[ip]; ok {
+			pending[ip] = append(idx, i)
+			continue
+		}
+
+		if err := c.Request(ip); err != nil {
+			results[i].Err = err
+			continue
+		}
+		pending[ip] = []int{i}
+	}
+
+	deadline := time.Now().Add(d)
+	err := c.SetReadDeadline(deadline)
+	defer c.SetReadDeadline(time.Time{})
+
+	for err == nil && len(pending) > 0 && time.Now().Before(deadline) {
+		var arp *Packet
+		arp, _, err = c.Read()
+		if err != nil {
+			break
 		}
 
-		if arp.Operation != OperationReply || arp.SenderIP != ip {
+		idx, ok := pending[arp.SenderIP]
+		if !ok || !isReplyFor(arp, arp.SenderIP) {
 			continue
 		}
+		for _, i := range idx {
+			results[i].HardwareAddr = arp.SenderHardwareAddr
+		}
+		delete(pending, arp.SenderIP)
+	}
+
+	if err == nil || isTimeout(err) {
+		err = ErrRequestTimeout
+	}
+	for _, idx := range pending {
+		for _, i := range idx {
+			results[i].Err = err
+		}
+	}
+
+	return results
+}
+
+// ResolveOptions configures retransmission for ResolveRetry.
+type ResolveOptions struct {
+	// Retries is the number of additional requests sent if no reply
+	// arrives, so up to Retries+1 requests are sent in total.
+	Retries int
 
-		return arp.SenderHardwareAddr, nil
+	// Interval is the amount of time to wait for a reply to the first
+	// request.
+	Interval time.Duration
+
+	// Backoff multiplies the wait after each successive request, so the
+	// wait after request n, counting from zero, is Interval*(Backoff^n).
+	// If less than 1, the wait is always Interval.
+	Backoff float64
+}
+
+// ResolveRetry performs ARP requests for ip, retrying with exponential
+// backoff as configured by opts, since replies are frequently dropped on
+// busy networks.  The hardware address from the first matching reply is
+// returned.  If no reply arrives after every attempt, ErrNoReply is
+// returned.
+//
+// ResolveRetry overrides any read deadline set on the Client, and clears it
+// before returning.
+func (c *Client) ResolveRetry(ip netip.Addr, opts ResolveOptions) (net.HardwareAddr, error) {
+	backoff := opts.Backoff
+	if backoff < 1 {
+		backoff = 1
+	}
+
+	for i := 0; i <= opts.Retries; i++ {
+		d := time.Duration(float64(opts.Interval) * math.Pow(backoff, float64(i)))
+
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		arp, _, err := c.resolveContext(ctx, ip)
+		cancel()
+
+		switch {
+		case err == nil:
+			return arp.SenderHardwareAddr, nil
+		case err != context.DeadlineExceeded:
+			return nil, err
+		}
+	}
+
+	return nil, ErrNoReply
+}
+
+// ResolveSchedule performs ARP requests for ip following a custom
+// retransmission schedule, which is useful on high latency links where
+// fixed retry intervals are a poor fit.  A request is sent and a reply is
+// awaited for schedule[0], then another request is sent and a reply is
+// awaited for schedule[1], and so on.  The hardware address from the first
+// matching reply is returned immediately.
+//
+// If the schedule is exhausted without a reply, ErrRequestTimeout is
+// returned.  If ctx is canceled first, ctx.Err() is returned.
+// ResolveSchedule overrides any read deadline set on the Client, and clears
+// it before returning.
+func (c *Client) ResolveSchedule(ctx context.Context, ip netip.Addr, schedule []time.Duration) (net.HardwareAddr, error) {
+	for _, d := range schedule {
+		sctx, cancel := context.WithTimeout(ctx, d)
+		arp, _, err := c.resolveContext(sctx, ip)
+		cancel()
+
+		switch {
+		case err == nil:
+			return arp.SenderHardwareAddr, nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case err != context.DeadlineExceeded:
+			return nil, err
+		}
+	}
+
+	return nil, ErrRequestTimeout
+}
+
+// Flush reads and discards all packets which have been received by the
+// Client, until no packets arrive within the duration d.  Flush is useful
+// before performing a new request, to discard stale replies which would
+// otherwise be read first.
+//
+// Flush overrides any read deadline set on the Client, and clears it before
+// returning.
+func (c *Client) Flush(d time.Duration) error {
+	defer c.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, c.readBufferSize())
+	for {
+		if err := c.SetReadDeadline(time.Now().Add(d)); err != nil {
+			return err
+		}
+
+		if _, _, err := c.p.ReadFrom(buf); err != nil {
+			if isTimeout(err) {
+				return nil
+			}
+			return err
+		}
 	}
 }
 
 // Read reads a single ARP packet and returns it, together with its
-// ethernet frame.
+// ethernet frame.  A Client created by Dial receives ARP packets from 802.1Q
+// VLAN tagged frames only after the kernel has removed the VLAN tag, so the
+// frame's VLAN field is always nil.
+//
+// Each frame is read into a new buffer sized according to the Client's
+// ReadBufferSize.
 func (c *Client) Read() (*Packet, *ethernet.Frame, error) {
-	buf := make([]byte, 128)
+	return c.read(false)
+}
+
+// read implements Read.  If skipMalformed is true, frames which cannot be
+// parsed are skipped along with non-ARP frames, so only errors from the
+// underlying net.PacketConn are returned.
+func (c *Client) read(skipMalformed bool) (*Packet, *ethernet.Frame, error) {
+	buf := make([]byte, c.readBufferSize())
 	for {
 		n, _, err := c.p.ReadFrom(buf)
 		if err != nil {
@@ -144,7 +784,7 @@ func (c *Client) Read() (*Packet, *ethernet.Frame, error) {
 
 		p, eth, err := parsePacket(buf[:n])
 		if err != nil {
-			if err == errInvalidARPPacket {
+			if err == errInvalidARPPacket || skipMalformed {
 				continue
 			}
 			return nil, nil, err
@@ -153,23 +793,125 @@ func (c *Client) Read() (*Packet, *ethernet.Frame, error) {
 	}
 }
 
+// readContext reads a single ARP packet and its ethernet frame in the same
+// way as Read, but returns ctx.Err() if ctx is canceled or its deadline is
+// exceeded first.  The Client's read deadline is used to interrupt reads,
+// and is cleared before readContext returns.
+func (c *Client) readContext(ctx context.Context) (*Packet, *ethernet.Frame, error) {
+	return c.readContextFunc(ctx, c.Read)
+}
+
+// monitorContext reads a single ARP packet and its ethernet frame in the
+// same way as readContext, but skips frames which cannot be parsed, so only
+// errors from the socket or ctx are returned.  Passive monitors use it so a
+// single malformed frame does not end monitoring.
+func (c *Client) monitorContext(ctx context.Context) (*Packet, *ethernet.Frame, error) {
+	return c.readContextFunc(ctx, func() (*Packet, *ethernet.Frame, error) {
+		return c.read(true)
+	})
+}
+
+// readContextFunc implements readContext and monitorContext, using read to
+// read each packet.
+func (c *Client) readContextFunc(ctx context.Context, read func() (*Packet, *ethernet.Frame, error)) (*Packet, *ethernet.Frame, error) {
+	defer c.SetReadDeadline(time.Time{})
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+
+		// Wake up periodically to check for cancelation, or at the
+		// context's deadline if it is sooner.
+		d := time.Now().Add(contextPollInterval)
+		cd, ok := ctx.Deadline()
+		if ok && cd.Before(d) {
+			d = cd
+		}
+		if err := c.SetReadDeadline(d); err != nil {
+			return nil, nil, err
+		}
+
+		p, eth, err := read()
+		if err == nil {
+			return p, eth, nil
+		}
+		if !isTimeout(err) {
+			return nil, nil, err
+		}
+
+		// The context's timer may not have fired yet when the read
+		// deadline is reached.
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if ok && !time.Now().Before(cd) {
+			return nil, nil, context.DeadlineExceeded
+		}
+	}
+}
+
+// ReceiveInto reads a single ARP packet into buf and returns it, together
+// with the number of bytes read into buf.  Unlike Read, ReceiveInto does not
+// allocate, so it is suitable for reading packets in a tight loop with a
+// single reused buffer.
+//
+// The returned Packet is reused by the next call to ReceiveInto, and its
+// hardware address fields alias buf.  Callers which need to retain a Packet
+// or its fields after reusing buf must copy them first, such as by using
+// Packet.Clone.
+func (c *Client) ReceiveInto(buf []byte) (*Packet, int, error) {
+	for {
+		n, _, err := c.p.ReadFrom(buf)
+		if err != nil {
+			return nil, n, err
+		}
+
+		b, err := arpPayload(buf[:n])
+		if err != nil {
+			if err == errInvalidARPPacket {
+				continue
+			}
+			return nil, n, err
+		}
+
+		l, err := c.rp.unmarshalHeader(b)
+		if err != nil {
+			return nil, n, err
+		}
+		if err := c.rp.unmarshalAddrs(b[8:l]); err != nil {
+			return nil, n, err
+		}
+
+		return &c.rp, n, nil
+	}
+}
+
 // WriteTo writes a single ARP packet to addr. Note that addr should,
 // but doesn't have to, match the target hardware address of the ARP
 // packet.
+//
+// The ethernet frame carrying the packet is always zero-padded to the
+// minimum ethernet frame length of 60 bytes (excluding the frame check
+// sequence), so raw sockets which do not pad undersized frames will not
+// transmit frames that switches would drop.
 func (c *Client) WriteTo(p *Packet, addr net.HardwareAddr) error {
-	pb, err := p.MarshalBinary()
-	if err != nil {
-		return err
-	}
+	return c.writeTo(p, addr, nil)
+}
 
-	f := &ethernet.Frame{
-		Destination: addr,
-		Source:      p.SenderHardwareAddr,
-		EtherType:   ethernet.EtherTypeARP,
-		Payload:     pb,
-	}
+// WriteToVLAN writes a single ARP packet to addr in the same way as WriteTo,
+// but tags the ethernet frame with the 802.1Q VLAN identified by vlan.  This
+// allows replying to a packet received on a trunk port on the VLAN it
+// arrived on, as reported by the VLAN field of the frame returned by Read.
+//
+// If vlan is not a valid VLAN ID, ethernet.ErrInvalidVLAN is returned.
+func (c *Client) WriteToVLAN(p *Packet, addr net.HardwareAddr, vlan uint16) error {
+	return c.writeTo(p, addr, &ethernet.VLAN{ID: vlan})
+}
 
-	fb, err := f.MarshalBinary()
+// writeTo writes p to addr in an ethernet frame with an optional VLAN tag.
+func (c *Client) writeTo(p *Packet, addr net.HardwareAddr, vlan *ethernet.VLAN) error {
+	fb, err := marshalFrame(p, p.SenderHardwareAddr, addr, vlan)
 	if err != nil {
 		return err
 	}
@@ -186,7 +928,7 @@ func (c *Client) WriteTo(p *Packet, addr net.HardwareAddr) error {
 // For more fine-grained control, use WriteTo to write a custom
 // response.
 func (c *Client) Reply(req *Packet, hwAddr net.HardwareAddr, ip netip.Addr) error {
-	p, err := NewPacket(OperationReply, hwAddr, ip, req.SenderHardwareAddr, req.SenderIP)
+	p, err := c.newPacket(OperationReply, hwAddr, ip, req.SenderHardwareAddr, req.SenderIP)
 	if err != nil {
 		return err
 	}
@@ -204,6 +946,7 @@ func (c *Client) Reply(req *Packet, hwAddr net.HardwareAddr, ip netip.Addr) erro
 // SetDeadline sets the read and write deadlines associated with the
 // connection.
 func (c *Client) SetDeadline(t time.Time) error {
+	c.readDeadline = t
 	return c.p.SetDeadline(t)
 }
 
@@ -212,6 +955,7 @@ func (c *Client) SetDeadline(t time.Time) error {
 // (see type net.Error) instead of blocking.
 // A zero value for t means a raw socket read will not time out.
 func (c *Client) SetReadDeadline(t time.Time) error {
+	c.readDeadline = t
 	return c.p.SetReadDeadline(t)
 }
 
@@ -231,12 +975,75 @@ func (c Client) HardwareAddr() net.HardwareAddr {
 	return c.ifi.HardwareAddr
 }
 
+// HardwareType returns the ARP hardware type of the interface associated
+// with the connection, which is used in the packets the Client creates.
+func (c *Client) HardwareType() HardwareType {
+	return c.hwType
+}
+
+// InterfaceIndex returns the index of the interface associated with the
+// connection, which may be used to correlate ARP activity with other
+// interface events, such as those from netlink.
+func (c *Client) InterfaceIndex() int {
+	return c.ifi.Index
+}
+
+// MatchPrefixes reports whether ip is contained in any of prefixes.  It
+// returns false if prefixes is empty.
+func MatchPrefixes(ip netip.Addr, prefixes []netip.Prefix) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBufferSize returns the size of the buffer used to read a single frame.
+func (c *Client) readBufferSize() int {
+	if c.ReadBufferSize > 0 {
+		return c.ReadBufferSize
+	}
+
+	if c.ifi != nil && c.ifi.MTU > 0 && c.ifi.MTU+frameOverhead < maxFrameLength {
+		return c.ifi.MTU + frameOverhead
+	}
+	return maxFrameLength
+}
+
+// isTimeout reports whether err is a timeout error.
+func isTimeout(err error) bool {
+	var nerr net.Error
+	return errors.As(err, &nerr) && nerr.Timeout()
+}
+
+// isReplyFor reports whether p is an ARP reply for ip.  Replies with an
+// unspecified sender IPv4 address, sent by some broken network stacks, are
+// never considered a reply for any address.
+func isReplyFor(p *Packet, ip netip.Addr) bool {
+	if !p.SenderIP.IsValid() || p.SenderIP.IsUnspecified() {
+		return false
+	}
+
+	return p.Operation == OperationReply && p.SenderIP == ip
+}
+
+// containsHardwareAddr reports whether mac is present in macs.
+func containsHardwareAddr(macs []net.HardwareAddr, mac net.HardwareAddr) bool {
+	for _, m := range macs {
+		if bytes.Equal(m, mac) {
+			return true
+		}
+	}
+	return false
+}
+
 // firstIPv4Addr attempts to retrieve the first detected IPv4 address from an
 // input slice of network addresses.
-func firstIPv4Addr(addrs []netip.Addr) (netip.Addr, error) {
+func firstIPv4Addr(addrs []netip.Prefix) (netip.Addr, error) {
 	for _, a := range addrs {
-		if a.Is4() {
-			return a, nil
+		if a.Addr().Is4() {
+			return a.Addr(), nil
 		}
 	}
 	return netip.Addr{}, errNoIPv4Addr
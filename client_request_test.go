@@ -2,11 +2,18 @@ package arp
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net"
 	"net/netip"
+	"os"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/mdlayher/ethernet"
 )
 
 func ipv6loopback() netip.Addr {
@@ -253,6 +260,39 @@ func TestClientRequestARPResponseWrongSenderIP(t *testing.T) {
 	}
 }
 
+func TestClientRequestARPResponseUnspecifiedSenderIP(t *testing.T) {
+	c := &Client{
+		ifi: &net.Interface{
+			HardwareAddr: net.HardwareAddr{0, 0, 0, 0, 0, 0},
+		},
+		ip: netip.AddrFrom4([4]byte{192, 168, 1, 1}),
+		p: &bufferReadFromPacketConn{
+			b: bytes.NewBuffer(append([]byte{
+				// Ethernet frame
+				0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0, 0, 0,
+				0x08, 0x06,
+				// ARP reply from a broken network stack
+				0, 1,
+				0x08, 0x06,
+				6,
+				4,
+				0, 2,
+				0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+				0, 0, 0, 0, // Unspecified IP address
+				0, 0, 0, 0, 0, 0,
+				192, 168, 1, 1,
+			}, make([]byte, 46)...)),
+		},
+	}
+
+	_, got := c.Resolve(netip.IPv4Unspecified())
+	if want := io.EOF; want != got {
+		t.Fatalf("unexpected error while reading ARP response with unspecified sender IP:\n- want: %v\n-  got: %v",
+			want, got)
+	}
+}
+
 func TestClientRequestOK(t *testing.T) {
 	c := &Client{
 		ifi: &net.Interface{
@@ -291,6 +331,729 @@ func TestClientRequestOK(t *testing.T) {
 	}
 }
 
+func TestClientBuildRequest(t *testing.T) {
+	c := testClient(t, nil)
+
+	p, err := c.buildRequest(netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationRequest,
+		SenderHardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		SenderIP:           netip.MustParseAddr("192.168.1.1"),
+		TargetHardwareAddr: ethernet.Broadcast,
+		TargetIP:           netip.MustParseAddr("192.168.1.10"),
+	}
+	if got := p; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientBuildRequestSourcePolicy(t *testing.T) {
+	c, err := newClient(&net.Interface{
+		HardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}, nil, []netip.Prefix{
+		netip.MustParsePrefix("192.168.1.1/24"),
+		netip.MustParsePrefix("10.0.0.1/8"),
+		netip.MustParsePrefix("10.1.0.1/16"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Choose the address of the most specific interface prefix which
+	// contains the target.  Modifying the candidates must not affect the
+	// Client.
+	c.SourcePolicy = func(candidates []netip.Prefix, target netip.Addr) netip.Addr {
+		var best netip.Prefix
+		for i, p := range candidates {
+			if p.Contains(target) && (!best.IsValid() || p.Bits() > best.Bits()) {
+				best = p
+			}
+			candidates[i] = netip.Prefix{}
+		}
+
+		return best.Addr()
+	}
+
+	tests := []struct {
+		target netip.Addr
+		src    netip.Addr
+		err    error
+	}{
+		{
+			target: netip.MustParseAddr("10.0.0.50"),
+			src:    netip.MustParseAddr("10.0.0.1"),
+		},
+		{
+			target: netip.MustParseAddr("10.1.2.3"),
+			src:    netip.MustParseAddr("10.1.0.1"),
+		},
+		{
+			target: netip.MustParseAddr("192.168.1.10"),
+			src:    netip.MustParseAddr("192.168.1.1"),
+		},
+		{
+			// Shares 23 leading bits with 192.168.1.1, but is outside its
+			// prefix.
+			target: netip.MustParseAddr("192.168.0.10"),
+			err:    errNoIPv4Addr,
+		},
+	}
+
+	for i, tt := range tests {
+		p, err := c.buildRequest(tt.target)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] unexpected error: %v != %v", i, want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		if want, got := tt.src, p.SenderIP; want != got {
+			t.Fatalf("[%02d] unexpected sender IP for %v: %v != %v",
+				i, tt.target, want, got)
+		}
+	}
+}
+
+func TestClientRefresh(t *testing.T) {
+	c := testClient(t, nil)
+
+	ifi := &net.Interface{
+		HardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+	}
+	if err := c.refresh(ifi, []netip.Prefix{netip.MustParsePrefix("192.168.2.1/24")}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := c.buildRequest(netip.MustParseAddr("192.168.2.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := netip.MustParseAddr("192.168.2.1"), p.SenderIP; want != got {
+		t.Fatalf("unexpected sender IP: %v != %v", want, got)
+	}
+	if want, got := ifi.HardwareAddr, p.SenderHardwareAddr; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected sender hardware address: %v != %v", want, got)
+	}
+
+	// A refresh which leaves the interface without an IPv4 address keeps
+	// the Client usable, but its requests have no sender IPv4 address.
+	if err := c.refresh(&net.Interface{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.buildRequest(netip.MustParseAddr("192.168.2.10")); err != errNoIPv4Addr {
+		t.Fatalf("unexpected error after refresh without IPv4 address: %v", err)
+	}
+}
+
+func TestClientResolveReply(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	tests := []struct {
+		desc      string
+		dst       net.HardwareAddr
+		broadcast bool
+	}{
+		{
+			desc: "unicast",
+			dst:  net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		},
+		{
+			desc:      "broadcast",
+			dst:       ethernet.Broadcast,
+			broadcast: true,
+		},
+	}
+
+	for i, tt := range tests {
+		p, err := NewPacket(OperationReply, mac, ip,
+			net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}, netip.MustParseAddr("192.168.1.1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		frame := packetFrame(t, p, tt.dst)
+
+		c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+			return []timedFrame{{b: frame}}
+		}))
+
+		r, err := c.ResolveReply(ip)
+		if err != nil {
+			t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+		}
+
+		if want, got := mac, r.Packet.SenderHardwareAddr; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.broadcast, r.Broadcast; want != got {
+			t.Fatalf("[%02d] test %q, unexpected broadcast classification: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestClientResolveAllTimeout(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac1 := net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+	mac2 := net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}
+	mac3 := net.HardwareAddr{0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc}
+
+	c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+		return []timedFrame{
+			{b: replyFrame(t, mac1, ip)},
+			{b: replyFrame(t, mac2, ip), after: 10 * time.Millisecond},
+			{b: replyFrame(t, mac3, ip), after: 500 * time.Millisecond},
+		}
+	}))
+
+	macs, err := c.ResolveAllTimeout(ip, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to resolve: %v", err)
+	}
+
+	if want, got := 2, len(macs); want != got {
+		t.Fatalf("unexpected number of hardware addresses: %d != %d", want, got)
+	}
+	if want, got := mac1, macs[0]; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected first hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := mac2, macs[1]; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected second hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientResolveAllTimeoutErrReadFrom(t *testing.T) {
+	errReadFrom := errors.New("test error")
+
+	c := testClient(t, &errReadFromPacketConn{err: errReadFrom})
+
+	_, err := c.ResolveAllTimeout(netip.MustParseAddr("192.168.1.10"), 100*time.Millisecond)
+	if want, got := errReadFrom, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+}
+
+func TestClientResolveMany(t *testing.T) {
+	var (
+		ip1 = netip.MustParseAddr("192.168.1.10")
+		ip2 = netip.MustParseAddr("192.168.1.20")
+		ip3 = netip.MustParseAddr("192.168.1.30")
+
+		mac1 = net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+		mac3 = net.HardwareAddr{0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc}
+	)
+
+	p := newReplyPacketConn(func(b []byte) []timedFrame {
+		req, _, err := parsePacket(b)
+		if err != nil {
+			t.Errorf("failed to parse request: %v", err)
+			return nil
+		}
+
+		// Reply out of order, and never reply for ip2.
+		switch req.TargetIP {
+		case ip1:
+			return []timedFrame{{b: replyFrame(t, mac1, ip1), after: 10 * time.Millisecond}}
+		case ip3:
+			return []timedFrame{{b: replyFrame(t, mac3, ip3)}}
+		}
+		return nil
+	})
+	c := testClient(t, p)
+
+	results := c.ResolveMany([]netip.Addr{ip1, ip2, ip3, ip1}, 50*time.Millisecond)
+
+	want := []Result{
+		{IP: ip1, HardwareAddr: mac1},
+		{IP: ip2, Err: ErrRequestTimeout},
+		{IP: ip3, HardwareAddr: mac3},
+		{IP: ip1, HardwareAddr: mac1},
+	}
+	if got := results; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected results:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// Repeated addresses are only requested once.
+	if want, got := 3, len(p.writes); want != got {
+		t.Fatalf("unexpected number of requests: %d != %d", want, got)
+	}
+}
+
+func TestClientFlush(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	stale := net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+	fresh := net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}
+
+	p := newReplyPacketConn(func(_ []byte) []timedFrame {
+		return []timedFrame{{b: replyFrame(t, fresh, ip)}}
+	})
+	c := testClient(t, p)
+
+	// Pre-load stale replies which were buffered before the request.
+	for i := 0; i < 3; i++ {
+		p.frames <- replyFrame(t, stale, ip)
+	}
+
+	if err := c.Flush(20 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	mac, err := c.Resolve(ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := fresh, mac; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestClientResolveDefaultTimeout(t *testing.T) {
+	// Continuously stream replies for an unrelated IPv4 address, so reads
+	// never block and never match.
+	frame := replyFrame(t, net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		netip.MustParseAddr("192.168.1.20"))
+
+	c := testClient(t, &repeatReadFromPacketConn{b: frame})
+	c.DefaultTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := c.Resolve(netip.MustParseAddr("192.168.1.10"))
+	if want, got := ErrRequestTimeout, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+
+	if d := time.Since(start); d < c.DefaultTimeout {
+		t.Fatalf("Resolve returned before default timeout: %v", d)
+	}
+}
+
+func TestClientResolveDefaultTimeoutReadDeadline(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+
+	// When the caller sets a read deadline, DefaultTimeout does not apply
+	// and the net.PacketConn's timeout error is returned.
+	c := testClient(t, newReplyPacketConn(nil))
+	c.DefaultTimeout = time.Hour
+	if err := c.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.Resolve(ip)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClientVerify(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	other := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+
+	tests := []struct {
+		desc   string
+		frames []timedFrame
+		ok     bool
+		mac    net.HardwareAddr
+		err    error
+	}{
+		{
+			desc:   "match",
+			frames: []timedFrame{{b: replyFrame(t, mac, ip)}},
+			ok:     true,
+			mac:    mac,
+		},
+		{
+			desc:   "mismatch",
+			frames: []timedFrame{{b: replyFrame(t, other, ip)}},
+			mac:    other,
+		},
+		{
+			desc: "no reply",
+			err:  ErrRequestTimeout,
+		},
+	}
+
+	for i, tt := range tests {
+		c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+			return tt.frames
+		}))
+		c.DefaultTimeout = 20 * time.Millisecond
+
+		ok, got, err := c.Verify(ip, mac)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("[%02d] test %q, unexpected verification result: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want := tt.mac; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestClientResolveTimed(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	const delay = 20 * time.Millisecond
+	c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+		return []timedFrame{{b: replyFrame(t, mac, ip), after: delay}}
+	}))
+
+	got, rtt, err := c.ResolveTimed(ip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := mac; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+	if rtt < delay || rtt > time.Second {
+		t.Fatalf("unexpected round-trip time: %v", rtt)
+	}
+}
+
+func TestClientResolveContext(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	tests := []struct {
+		desc   string
+		frames []timedFrame
+		cancel bool
+		mac    net.HardwareAddr
+		err    error
+	}{
+		{
+			desc: "OK",
+			frames: []timedFrame{
+				{b: replyFrame(t, net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}, netip.MustParseAddr("192.168.1.20"))},
+				{b: replyFrame(t, mac, ip)},
+			},
+			mac: mac,
+		},
+		{
+			desc: "deadline exceeded",
+			err:  context.DeadlineExceeded,
+		},
+		{
+			desc:   "canceled",
+			cancel: true,
+			err:    context.Canceled,
+		},
+	}
+
+	for i, tt := range tests {
+		c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+			return tt.frames
+		}))
+
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if tt.cancel {
+			ctx, cancel = context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+		} else {
+			ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+		}
+
+		got, err := c.ResolveContext(ctx, ip)
+		cancel()
+
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want := tt.mac; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestClientResolveRetry(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	opts := ResolveOptions{
+		Retries:  2,
+		Interval: 10 * time.Millisecond,
+		Backoff:  2,
+	}
+
+	tests := []struct {
+		desc   string
+		answer int
+		writes int
+		mac    net.HardwareAddr
+		err    error
+	}{
+		{
+			desc:   "OK first attempt",
+			answer: 1,
+			writes: 1,
+			mac:    mac,
+		},
+		{
+			desc:   "OK last attempt",
+			answer: 3,
+			writes: 3,
+			mac:    mac,
+		},
+		{
+			desc:   "no reply",
+			writes: 3,
+			err:    ErrNoReply,
+		},
+	}
+
+	for i, tt := range tests {
+		var requests int
+		p := newReplyPacketConn(func(_ []byte) []timedFrame {
+			requests++
+			if requests != tt.answer {
+				return nil
+			}
+
+			return []timedFrame{{b: replyFrame(t, mac, ip)}}
+		})
+		c := testClient(t, p)
+
+		start := time.Now()
+		got, err := c.ResolveRetry(ip, opts)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want := tt.mac; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.writes, len(p.writes); want != got {
+			t.Fatalf("[%02d] test %q, unexpected number of requests: %d != %d",
+				i, tt.desc, want, got)
+		}
+
+		// 10ms + 20ms + 40ms of backoff.
+		if d := time.Since(start); err != nil && d < 70*time.Millisecond {
+			t.Fatalf("[%02d] test %q, gave up before backoff elapsed: %v",
+				i, tt.desc, d)
+		}
+	}
+}
+
+func TestClientResolveSchedule(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	schedule := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		30 * time.Millisecond,
+	}
+
+	tests := []struct {
+		desc   string
+		answer int
+		writes int
+		mac    net.HardwareAddr
+		err    error
+	}{
+		{
+			desc:   "no reply",
+			writes: 3,
+			err:    ErrRequestTimeout,
+		},
+		{
+			desc:   "reply to second request",
+			answer: 2,
+			writes: 2,
+			mac:    mac,
+		},
+	}
+
+	for i, tt := range tests {
+		var requests int
+		p := newReplyPacketConn(func(_ []byte) []timedFrame {
+			requests++
+			if requests != tt.answer {
+				return nil
+			}
+
+			return []timedFrame{{b: replyFrame(t, mac, ip)}}
+		})
+		c := testClient(t, p)
+
+		got, err := c.ResolveSchedule(context.Background(), ip, schedule)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want := tt.mac; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.writes, len(p.writes); want != got {
+			t.Fatalf("[%02d] test %q, unexpected number of requests: %d != %d",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+// testClient creates a Client with fixed addresses which uses p as its
+// net.PacketConn.
+func testClient(t *testing.T, p net.PacketConn) *Client {
+	t.Helper()
+
+	c, err := newClient(&net.Interface{
+		HardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}, p, []netip.Prefix{netip.MustParsePrefix("192.168.1.1/24")})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	return c
+}
+
+// replyFrame builds an ethernet frame carrying an ARP reply from the
+// specified hardware and IPv4 addresses, addressed to the Client created by
+// testClient.
+func replyFrame(t *testing.T, mac net.HardwareAddr, ip netip.Addr) []byte {
+	t.Helper()
+
+	dst := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	p, err := NewPacket(OperationReply, mac, ip, dst, netip.MustParseAddr("192.168.1.1"))
+	if err != nil {
+		t.Fatalf("failed to create packet: %v", err)
+	}
+
+	return packetFrame(t, p, dst)
+}
+
+// packetFrame builds an ethernet frame carrying p, addressed to dst.
+func packetFrame(t *testing.T, p *Packet, dst net.HardwareAddr) []byte {
+	t.Helper()
+
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal packet: %v", err)
+	}
+
+	f := &ethernet.Frame{
+		Destination: dst,
+		Source:      p.SenderHardwareAddr,
+		EtherType:   ethernet.EtherTypeARP,
+		Payload:     pb,
+	}
+
+	fb, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal frame: %v", err)
+	}
+
+	return fb
+}
+
+// A timedFrame is a frame which a replyPacketConn makes available for
+// reading after a delay.
+type timedFrame struct {
+	b     []byte
+	after time.Duration
+}
+
+// replyPacketConn is a net.PacketConn which passes each frame written by its
+// WriteTo method to a reply function, and makes the frames returned by the
+// function available to its ReadFrom method.  Read deadlines are honored.
+type replyPacketConn struct {
+	reply  func(b []byte) []timedFrame
+	frames chan []byte
+
+	mu       sync.Mutex
+	deadline time.Time
+	writes   [][]byte
+
+	noopPacketConn
+}
+
+func newReplyPacketConn(reply func(b []byte) []timedFrame) *replyPacketConn {
+	return &replyPacketConn{
+		reply:  reply,
+		frames: make(chan []byte, 1024),
+	}
+}
+
+func (p *replyPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	p.mu.Lock()
+	d := p.deadline
+	p.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if !d.IsZero() {
+		timer := time.NewTimer(time.Until(d))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case f := <-p.frames:
+		return copy(b, f), nil, nil
+	case <-timeout:
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+func (p *replyPacketConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	p.mu.Lock()
+	p.writes = append(p.writes, append([]byte(nil), b...))
+	p.mu.Unlock()
+
+	if p.reply == nil {
+		return len(b), nil
+	}
+
+	for _, f := range p.reply(b) {
+		f := f
+		if f.after == 0 {
+			p.frames <- f.b
+			continue
+		}
+
+		time.AfterFunc(f.after, func() { p.frames <- f.b })
+	}
+
+	return len(b), nil
+}
+
+func (p *replyPacketConn) SetDeadline(t time.Time) error { return p.SetReadDeadline(t) }
+
+func (p *replyPacketConn) SetReadDeadline(t time.Time) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.deadline = t
+	return nil
+}
+
 // bufferReadFromPacketConn is a net.PacketConn which copies bytes from its
 // embedded buffer into b when when its ReadFrom method is called.
 type bufferReadFromPacketConn struct {
@@ -1,11 +1,18 @@
 package arp
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"net"
 	"net/netip"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/mdlayher/ethernet"
+	"github.com/mdlayher/packet"
+	"golang.org/x/net/bpf"
 )
 
 func TestClientClose(t *testing.T) {
@@ -84,25 +91,125 @@ func TestClientHardwareAddr(t *testing.T) {
 	}
 }
 
+func TestClientInterfaceIndex(t *testing.T) {
+	c := &Client{
+		ifi: &net.Interface{
+			Index: 3,
+		},
+	}
+
+	if want, got := 3, c.InterfaceIndex(); want != got {
+		t.Fatalf("unexpected interface index: %d != %d", want, got)
+	}
+}
+
+func TestClientReadBufferSize(t *testing.T) {
+	tests := []struct {
+		desc string
+		c    *Client
+		n    int
+	}{
+		{
+			desc: "no interface",
+			c:    &Client{},
+			n:    1046,
+		},
+		{
+			desc: "jumbo frame MTU capped at largest ARP frame",
+			c: &Client{
+				ifi: &net.Interface{MTU: 9000},
+			},
+			n: 1046,
+		},
+		{
+			desc: "small interface MTU",
+			c: &Client{
+				ifi: &net.Interface{MTU: 576},
+			},
+			n: 594,
+		},
+		{
+			desc: "ReadBufferSize overrides MTU",
+			c: &Client{
+				ReadBufferSize: 256,
+				ifi:            &net.Interface{MTU: 9000},
+			},
+			n: 256,
+		},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.n, tt.c.readBufferSize(); want != got {
+			t.Fatalf("[%02d] test %q, unexpected buffer size: %d != %d",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestClientReadLargeHardwareAddr(t *testing.T) {
+	// A packet with 64 byte hardware addresses does not fit in a 128 byte
+	// buffer, so it must not be truncated.
+	hw := net.HardwareAddr(bytes.Repeat([]byte{0xaa}, 64))
+	want, err := NewPacket(OperationRequest, hw, netip.MustParseAddr("192.168.1.10"),
+		hw, netip.MustParseAddr("192.168.1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := newReplyPacketConn(nil)
+	p.frames <- packetFrame(t, want, ethernet.Broadcast)
+
+	c := testClient(t, p)
+	got, _, err := c.Read()
+	if err != nil {
+		t.Fatalf("failed to read: %v", err)
+	}
+
+	if !want.Equal(got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
 func Test_newClient(t *testing.T) {
 	tests := []struct {
 		desc  string
-		addrs []netip.Addr
+		addrs []netip.Prefix
 		c     *Client
 		err   error
 	}{
 		{
 			desc: "no network addresses",
-			c:    &Client{},
-			err:  errNoIPv4Addr,
+			c: &Client{
+				hwType: HardwareTypeEthernet,
+			},
 		},
 		{
 			desc: "OK",
-			addrs: []netip.Addr{
-				netip.MustParseAddr("192.168.1.1"),
+			addrs: []netip.Prefix{
+				netip.MustParsePrefix("192.168.1.1/24"),
+			},
+			c: &Client{
+				ip:     netip.MustParseAddr("192.168.1.1"),
+				hwType: HardwareTypeEthernet,
+				addrs: []netip.Prefix{
+					netip.MustParsePrefix("192.168.1.1/24"),
+				},
+			},
+		},
+		{
+			desc: "OK, IPv6 address ignored",
+			addrs: []netip.Prefix{
+				netip.MustParsePrefix("fe80::1/64"),
+				netip.MustParsePrefix("192.168.1.1/24"),
+				netip.MustParsePrefix("10.0.0.1/8"),
 			},
 			c: &Client{
-				ip: netip.MustParseAddr("192.168.1.1"),
+				ip:     netip.MustParseAddr("192.168.1.1"),
+				hwType: HardwareTypeEthernet,
+				addrs: []netip.Prefix{
+					netip.MustParsePrefix("192.168.1.1/24"),
+					netip.MustParsePrefix("10.0.0.1/8"),
+				},
 			},
 		},
 	}
@@ -125,6 +232,427 @@ func Test_newClient(t *testing.T) {
 	}
 }
 
+func TestNewClientWithConn(t *testing.T) {
+	ifi := &net.Interface{
+		HardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}
+
+	if _, err := NewClientWithConn(ifi, &noopPacketConn{}, netip.MustParseAddr("fe80::1")); err != ErrInvalidIP {
+		t.Fatalf("unexpected error for IPv6 address: %v", err)
+	}
+
+	ip := netip.MustParseAddr("192.168.1.1")
+	c, err := NewClientWithConn(ifi, &noopPacketConn{}, ip)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	p, err := c.buildRequest(netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if want, got := ip, p.SenderIP; want != got {
+		t.Fatalf("unexpected sender IP: %v != %v", want, got)
+	}
+}
+
+func Test_dialRetry(t *testing.T) {
+	// A real interface is needed to look up addresses once the listen
+	// function succeeds.
+	ifi := loopbackInterface(t)
+	errListen := errors.New("test error")
+
+	tests := []struct {
+		desc     string
+		attempts int
+		fail     int
+		noAddr   int
+		calls    int
+		err      error
+	}{
+		{
+			desc:     "OK first attempt",
+			attempts: 3,
+			calls:    1,
+		},
+		{
+			desc:     "OK second attempt",
+			attempts: 3,
+			fail:     1,
+			calls:    2,
+		},
+		{
+			desc:     "OK after no IPv4 address",
+			attempts: 3,
+			noAddr:   2,
+			calls:    3,
+		},
+		{
+			desc:     "attempts exhausted",
+			attempts: 3,
+			fail:     5,
+			calls:    3,
+			err:      errListen,
+		},
+		{
+			desc:     "attempts exhausted with no IPv4 address",
+			attempts: 3,
+			fail:     1,
+			noAddr:   5,
+			calls:    3,
+			err:      errNoIPv4Addr,
+		},
+		{
+			desc:     "zero attempts makes one attempt",
+			attempts: 0,
+			fail:     5,
+			calls:    1,
+			err:      errListen,
+		},
+		{
+			desc:     "negative attempts makes one attempt",
+			attempts: -1,
+			calls:    1,
+		},
+	}
+
+	for i, tt := range tests {
+		// Start with an interface index which does not exist and so has no
+		// addresses, and switch to the loopback interface once the attempts
+		// without an IPv4 address are used up.
+		tifi := *ifi
+		tifi.Index = 1<<31 - 1
+
+		var (
+			calls int
+			conns []*closeCapturePacketConn
+		)
+
+		c, err := dialRetry(context.Background(), &tifi, nil, tt.attempts, time.Millisecond, func(lifi *net.Interface, _ *packet.Config) (net.PacketConn, error) {
+			if lifi != &tifi {
+				t.Fatalf("[%02d] test %q, unexpected interface: %v", i, tt.desc, lifi)
+			}
+
+			calls++
+			if calls <= tt.fail {
+				return nil, errListen
+			}
+			if calls > tt.fail+tt.noAddr {
+				lifi.Index = ifi.Index
+			}
+
+			p := &closeCapturePacketConn{}
+			conns = append(conns, p)
+			return p, nil
+		})
+
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if err == nil && c == nil {
+			t.Fatalf("[%02d] test %q, nil Client", i, tt.desc)
+		}
+		if want, got := tt.calls, calls; want != got {
+			t.Fatalf("[%02d] test %q, unexpected number of dial attempts: %d != %d",
+				i, tt.desc, want, got)
+		}
+
+		// Every connection except the one used by a returned Client must
+		// be closed.
+		for j, p := range conns {
+			if want, got := c == nil || j < len(conns)-1, p.closed; want != got {
+				t.Fatalf("[%02d] test %q, unexpected closed state for connection %d: %v != %v",
+					i, tt.desc, j, want, got)
+			}
+		}
+	}
+}
+
+func Test_dialRetryContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := dialRetry(ctx, &net.Interface{}, nil, 3, time.Hour, func(_ *net.Interface, _ *packet.Config) (net.PacketConn, error) {
+		return nil, errors.New("test error")
+	})
+	if want, got := context.Canceled, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+}
+
+// loopbackInterface returns a loopback interface with an IPv4 address, or
+// skips the test if none exists.
+func loopbackInterface(t *testing.T) *net.Interface {
+	t.Helper()
+
+	ifis, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("failed to list interfaces: %v", err)
+	}
+
+	for _, ifi := range ifis {
+		if ifi.Flags&net.FlagLoopback == 0 {
+			continue
+		}
+
+		addrs, err := interfaceAddrs(&ifi)
+		if err != nil {
+			continue
+		}
+		if _, err := firstIPv4Addr(addrs); err == nil {
+			ifi := ifi
+			return &ifi
+		}
+	}
+
+	t.Skip("no loopback interface with an IPv4 address")
+	return nil
+}
+
+func Test_dialConfig(t *testing.T) {
+	errListen := errors.New("test error")
+	ifi := &net.Interface{Name: "eth0"}
+
+	tests := []struct {
+		desc string
+		cfg  *packet.Config
+	}{
+		{
+			desc: "nil config",
+		},
+		{
+			desc: "custom config",
+			cfg: &packet.Config{
+				Filter: []bpf.RawInstruction{{Op: 0x6, K: 0xffff}},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		var got *packet.Config
+		_, err := dialConfig(ifi, tt.cfg, func(lifi *net.Interface, cfg *packet.Config) (net.PacketConn, error) {
+			if lifi != ifi {
+				t.Fatalf("[%02d] test %q, unexpected interface: %v", i, tt.desc, lifi)
+			}
+
+			got = cfg
+			return nil, errListen
+		})
+		if want, got := errListen, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+
+		if want := tt.cfg; want != got {
+			t.Fatalf("[%02d] test %q, config was not forwarded: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestClientReceiveInto(t *testing.T) {
+	frame := append([]byte{
+		// Ethernet frame with VLAN tag
+		0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		0x81, 0x00,
+		0x00, 0x0a,
+		0x08, 0x06,
+		// ARP Packet
+		0, 1,
+		0x08, 0x00,
+		6,
+		4,
+		0, 2,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		192, 168, 1, 10,
+		0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+		192, 168, 1, 1,
+	}, make([]byte, 14)...)
+
+	c := &Client{
+		p: &repeatReadFromPacketConn{b: frame},
+	}
+
+	buf := make([]byte, 128)
+	p, n, err := c.ReceiveInto(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := len(frame), n; want != got {
+		t.Fatalf("unexpected number of bytes read: %d != %d", want, got)
+	}
+
+	want := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       0x0800,
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationReply,
+		SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		SenderIP:           netip.MustParseAddr("192.168.1.10"),
+		TargetHardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		TargetIP:           netip.MustParseAddr("192.168.1.1"),
+	}
+	if got := p; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	// Hardware addresses alias the input buffer, unless cloned.
+	clone := p.Clone()
+	buf[26] = 0xff
+	if want, got := byte(0xff), p.SenderHardwareAddr[0]; want != got {
+		t.Fatalf("sender hardware address does not alias buffer: %#x != %#x", want, got)
+	}
+	if want, got := byte(0xaa), clone.SenderHardwareAddr[0]; want != got {
+		t.Fatalf("cloned sender hardware address aliases buffer: %#x != %#x", want, got)
+	}
+}
+
+func TestClientWriteToPadding(t *testing.T) {
+	p := newReplyPacketConn(nil)
+	c := testClient(t, p)
+
+	// An ethernet ARP packet is 28 bytes, so with a 14 byte ethernet header,
+	// the frame would be 42 bytes without padding.
+	if err := c.Request(netip.MustParseAddr("192.168.1.10")); err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(p.writes); want != got {
+		t.Fatalf("unexpected number of writes: %d != %d", want, got)
+	}
+	if want, got := 60, len(p.writes[0]); got < want {
+		t.Fatalf("frame was not padded to minimum ethernet length: %d < %d", got, want)
+	}
+}
+
+func TestClientWriteToVLAN(t *testing.T) {
+	p := newReplyPacketConn(nil)
+	c := testClient(t, p)
+
+	arp, err := NewPacket(
+		OperationReply,
+		c.HardwareAddr(), netip.MustParseAddr("192.168.1.1"),
+		net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, netip.MustParseAddr("192.168.1.10"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.WriteToVLAN(arp, arp.TargetHardwareAddr, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	f, got, err := ParseEthernetARP(p.writes[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.VLAN == nil {
+		t.Fatal("frame has no VLAN tag")
+	}
+	if want, got := uint16(10), f.VLAN.ID; want != got {
+		t.Fatalf("unexpected VLAN ID: %d != %d", want, got)
+	}
+	if !arp.Equal(got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", arp, got)
+	}
+
+	if err := c.WriteToVLAN(arp, arp.TargetHardwareAddr, 4095); err != ethernet.ErrInvalidVLAN {
+		t.Fatalf("unexpected error for invalid VLAN: %v", err)
+	}
+}
+
+func TestMatchPrefixes(t *testing.T) {
+	prefixes := []netip.Prefix{
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("192.168.1.0/24"),
+		netip.MustParsePrefix("10.0.0.0/8"),
+	}
+
+	tests := []struct {
+		desc     string
+		ip       netip.Addr
+		prefixes []netip.Prefix
+		ok       bool
+	}{
+		{
+			desc: "no prefixes",
+			ip:   netip.MustParseAddr("192.168.1.1"),
+		},
+		{
+			desc:     "overlapping prefixes",
+			ip:       netip.MustParseAddr("192.168.1.1"),
+			prefixes: prefixes,
+			ok:       true,
+		},
+		{
+			desc:     "last prefix",
+			ip:       netip.MustParseAddr("10.1.2.3"),
+			prefixes: prefixes,
+			ok:       true,
+		},
+		{
+			desc:     "no match",
+			ip:       netip.MustParseAddr("172.16.0.1"),
+			prefixes: prefixes,
+		},
+		{
+			desc:     "IPv6 address",
+			ip:       netip.MustParseAddr("fe80::1"),
+			prefixes: prefixes,
+		},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.ok, MatchPrefixes(tt.ip, tt.prefixes); want != got {
+			t.Fatalf("[%02d] test %q, unexpected match: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func BenchmarkClientReceiveInto(b *testing.B) {
+	p, err := NewPacket(
+		OperationReply,
+		net.HardwareAddr{0xad, 0xbe, 0xef, 0xde, 0xad, 0xde},
+		netip.MustParseAddr("192.168.1.10"),
+		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		netip.MustParseAddr("192.168.1.1"),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	frame := append([]byte{
+		0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+		0xad, 0xbe, 0xef, 0xde, 0xad, 0xde,
+		0x08, 0x06,
+	}, pb...)
+
+	c := &Client{
+		p: &repeatReadFromPacketConn{b: frame},
+	}
+	buf := make([]byte, 128)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := c.ReceiveInto(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 type closeCapturePacketConn struct {
 	closed bool
 
@@ -161,6 +689,18 @@ func (p *deadlineCapturePacketConn) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+// repeatReadFromPacketConn is a net.PacketConn which copies the same bytes
+// into b each time its ReadFrom method is called.
+type repeatReadFromPacketConn struct {
+	b []byte
+
+	noopPacketConn
+}
+
+func (p *repeatReadFromPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	return copy(b, p.b), nil, nil
+}
+
 // noopPacketConn is a net.PacketConn which simply no-ops any input.  It is
 // embedded in other implementations so they do not have to implement every
 // single method.
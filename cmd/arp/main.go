@@ -0,0 +1,196 @@
+// Command arp sends a single, hand-crafted ARP packet using a network
+// interface, and prints any replies.  It is useful for testing how other
+// machines in a LAN respond to ARP requests, replies, and gratuitous ARP.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+)
+
+// A config is the configuration for a single ARP packet, parsed from flags.
+type config struct {
+	// iface is the network interface used to send the packet.
+	iface string
+
+	// op is the ARP operation to perform: "request", "reply", or
+	// "gratuitous".
+	op string
+
+	// sender is the sender IPv4 address; if invalid, the first IPv4 address
+	// of the interface is used.
+	sender netip.Addr
+
+	// target and targetHW are the target IPv4 and hardware addresses.
+	target   netip.Addr
+	targetHW net.HardwareAddr
+
+	// timeout is how long to wait for replies after sending.
+	timeout time.Duration
+}
+
+// parseFlags parses a config from command line arguments.
+func parseFlags(args []string) (*config, error) {
+	fs := flag.NewFlagSet("arp", flag.ContinueOnError)
+
+	var (
+		iface    = fs.String("i", "eth0", "network interface to use for ARP packet")
+		op       = fs.String("op", "request", `ARP operation to send: "request", "reply", or "gratuitous"`)
+		sender   = fs.String("sender", "", "sender IPv4 address (default: interface IPv4 address)")
+		target   = fs.String("target", "", "target IPv4 address")
+		targetHW = fs.String("hw", "", "target hardware address (default: broadcast)")
+		timeout  = fs.Duration("d", 1*time.Second, "time to wait for replies")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := &config{
+		iface:    *iface,
+		op:       *op,
+		targetHW: ethernet.Broadcast,
+		timeout:  *timeout,
+	}
+
+	switch cfg.op {
+	case "request", "reply", "gratuitous":
+	default:
+		return nil, fmt.Errorf("invalid ARP operation: %q", cfg.op)
+	}
+
+	if *sender != "" {
+		ip, err := netip.ParseAddr(*sender)
+		if err != nil || !ip.Is4() {
+			return nil, fmt.Errorf("invalid sender IPv4 address: %q", *sender)
+		}
+		cfg.sender = ip
+	}
+
+	// Gratuitous ARP always targets the sender's own address.
+	if cfg.op != "gratuitous" {
+		ip, err := netip.ParseAddr(*target)
+		if err != nil || !ip.Is4() {
+			return nil, fmt.Errorf("invalid target IPv4 address: %q", *target)
+		}
+		cfg.target = ip
+	}
+
+	if *targetHW != "" {
+		hw, err := net.ParseMAC(*targetHW)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target hardware address: %q", *targetHW)
+		}
+		cfg.targetHW = hw
+	}
+
+	return cfg, nil
+}
+
+// buildPacket builds the ARP packet described by cfg, using the hardware
+// and IPv4 addresses of the sending interface.  The ethernet destination
+// address for the packet is also returned.
+func buildPacket(cfg *config, hw net.HardwareAddr, ip netip.Addr) (*arp.Packet, net.HardwareAddr, error) {
+	if cfg.sender.IsValid() {
+		ip = cfg.sender
+	}
+
+	switch cfg.op {
+	case "request":
+		p, err := arp.NewPacket(arp.OperationRequest, hw, ip, cfg.targetHW, cfg.target)
+		return p, cfg.targetHW, err
+	case "reply":
+		p, err := arp.NewPacket(arp.OperationReply, hw, ip, cfg.targetHW, cfg.target)
+		return p, cfg.targetHW, err
+	case "gratuitous":
+		p, err := arp.NewPacket(arp.OperationRequest, hw, ip, ethernet.Broadcast, ip)
+		return p, ethernet.Broadcast, err
+	default:
+		return nil, nil, fmt.Errorf("invalid ARP operation: %q", cfg.op)
+	}
+}
+
+func main() {
+	cfg, err := parseFlags(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatal(err)
+	}
+
+	// Ensure valid network interface with an IPv4 address
+	ifi, err := net.InterfaceByName(cfg.iface)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ip, err := interfaceIPv4(ifi)
+	if err != nil && !cfg.sender.IsValid() {
+		log.Fatal(err)
+	}
+
+	p, dst, err := buildPacket(cfg, ifi.HardwareAddr, ip)
+	if err != nil {
+		log.Fatalf("failed to build ARP packet: %v", err)
+	}
+
+	c, err := arp.Dial(ifi)
+	if err != nil {
+		log.Fatalf("couldn't create ARP client: %v", err)
+	}
+	defer c.Close()
+
+	fmt.Printf("sent: %s\n", p)
+	if err := c.WriteTo(p, dst); err != nil {
+		log.Fatalf("failed to send ARP packet: %v", err)
+	}
+
+	// Print any replies from the target until the timeout elapses
+	if err := c.SetReadDeadline(time.Now().Add(cfg.timeout)); err != nil {
+		log.Fatal(err)
+	}
+	for {
+		reply, _, err := c.Read()
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return
+			}
+			log.Fatalf("error reading ARP replies: %v", err)
+		}
+
+		if reply.Operation != arp.OperationReply || reply.SenderIP != p.TargetIP {
+			continue
+		}
+
+		fmt.Printf("recv: %s\n", reply)
+	}
+}
+
+// interfaceIPv4 returns the first IPv4 address assigned to ifi.
+func interfaceIPv4(ifi *net.Interface) (netip.Addr, error) {
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	for _, a := range addrs {
+		prefix, err := netip.ParsePrefix(a.String())
+		if err != nil {
+			continue
+		}
+		if prefix.Addr().Is4() {
+			return prefix.Addr(), nil
+		}
+	}
+
+	return netip.Addr{}, fmt.Errorf("no IPv4 address available for interface %q", ifi.Name)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+)
+
+func Test_parseFlags(t *testing.T) {
+	tests := []struct {
+		desc string
+		args []string
+		cfg  *config
+		ok   bool
+	}{
+		{
+			desc: "invalid operation",
+			args: []string{"-op", "foo", "-target", "192.168.1.1"},
+		},
+		{
+			desc: "missing target",
+			args: []string{"-op", "request"},
+		},
+		{
+			desc: "IPv6 target",
+			args: []string{"-target", "::1"},
+		},
+		{
+			desc: "invalid sender",
+			args: []string{"-sender", "foo", "-target", "192.168.1.1"},
+		},
+		{
+			desc: "invalid hardware address",
+			args: []string{"-target", "192.168.1.1", "-hw", "foo"},
+		},
+		{
+			desc: "OK request",
+			args: []string{"-i", "eth1", "-op", "request", "-target", "192.168.1.1"},
+			cfg: &config{
+				iface:    "eth1",
+				op:       "request",
+				target:   netip.MustParseAddr("192.168.1.1"),
+				targetHW: ethernet.Broadcast,
+				timeout:  1 * time.Second,
+			},
+			ok: true,
+		},
+		{
+			desc: "OK reply",
+			args: []string{
+				"-op", "reply",
+				"-sender", "192.168.1.10",
+				"-target", "192.168.1.1",
+				"-hw", "de:ad:be:ef:de:ad",
+				"-d", "2s",
+			},
+			cfg: &config{
+				iface:    "eth0",
+				op:       "reply",
+				sender:   netip.MustParseAddr("192.168.1.10"),
+				target:   netip.MustParseAddr("192.168.1.1"),
+				targetHW: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+				timeout:  2 * time.Second,
+			},
+			ok: true,
+		},
+		{
+			desc: "OK gratuitous",
+			args: []string{"-op", "gratuitous"},
+			cfg: &config{
+				iface:    "eth0",
+				op:       "gratuitous",
+				targetHW: ethernet.Broadcast,
+				timeout:  1 * time.Second,
+			},
+			ok: true,
+		},
+	}
+
+	for i, tt := range tests {
+		cfg, err := parseFlags(tt.args)
+		if err != nil {
+			if tt.ok {
+				t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+			}
+
+			continue
+		}
+		if !tt.ok {
+			t.Fatalf("[%02d] test %q, expected an error", i, tt.desc)
+		}
+
+		if want, got := tt.cfg, cfg; want.iface != got.iface || want.op != got.op ||
+			want.sender != got.sender || want.target != got.target ||
+			!bytes.Equal(want.targetHW, got.targetHW) || want.timeout != got.timeout {
+			t.Fatalf("[%02d] test %q, unexpected config:\n- want: %+v\n-  got: %+v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func Test_buildPacket(t *testing.T) {
+	hw := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	ip := netip.MustParseAddr("192.168.1.10")
+	target := netip.MustParseAddr("192.168.1.1")
+
+	tests := []struct {
+		desc string
+		cfg  *config
+		op   arp.Operation
+		src  netip.Addr
+		dst  netip.Addr
+		eth  net.HardwareAddr
+	}{
+		{
+			desc: "request",
+			cfg: &config{
+				op:       "request",
+				target:   target,
+				targetHW: ethernet.Broadcast,
+			},
+			op:  arp.OperationRequest,
+			src: ip,
+			dst: target,
+			eth: ethernet.Broadcast,
+		},
+		{
+			desc: "reply with sender override",
+			cfg: &config{
+				op:       "reply",
+				sender:   netip.MustParseAddr("192.168.1.20"),
+				target:   target,
+				targetHW: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			},
+			op:  arp.OperationReply,
+			src: netip.MustParseAddr("192.168.1.20"),
+			dst: target,
+			eth: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		},
+		{
+			desc: "gratuitous",
+			cfg: &config{
+				op:       "gratuitous",
+				targetHW: ethernet.Broadcast,
+			},
+			op:  arp.OperationRequest,
+			src: ip,
+			dst: ip,
+			eth: ethernet.Broadcast,
+		},
+	}
+
+	for i, tt := range tests {
+		p, eth, err := buildPacket(tt.cfg, hw, ip)
+		if err != nil {
+			t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+		}
+
+		if want, got := tt.op, p.Operation; want != got {
+			t.Fatalf("[%02d] test %q, unexpected operation: %v != %v", i, tt.desc, want, got)
+		}
+		if want, got := hw, p.SenderHardwareAddr; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected sender hardware address: %v != %v", i, tt.desc, want, got)
+		}
+		if want, got := tt.src, p.SenderIP; want != got {
+			t.Fatalf("[%02d] test %q, unexpected sender IP: %v != %v", i, tt.desc, want, got)
+		}
+		if want, got := tt.dst, p.TargetIP; want != got {
+			t.Fatalf("[%02d] test %q, unexpected target IP: %v != %v", i, tt.desc, want, got)
+		}
+		if want, got := tt.eth, eth; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected ethernet destination: %v != %v", i, tt.desc, want, got)
+		}
+	}
+}
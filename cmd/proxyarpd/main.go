@@ -51,7 +51,7 @@ func main() {
 		}
 
 		// Ignore ARP replies
-		if pkt.Operation != arp.OperationRequest {
+		if !pkt.IsRequest() {
 			continue
 		}
 
@@ -0,0 +1,184 @@
+package arp
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// DADOptions configures the duplicate address detection performed by
+// ClaimAddress.  Each field corresponds to a constant from RFC 5227,
+// section 1.1.  Any zero field is replaced by the RFC's default value.
+type DADOptions struct {
+	// ProbeWait is the maximum random delay before the first probe.
+	// Default: 1 second.
+	ProbeWait time.Duration
+
+	// ProbeNum is the number of probes sent.  Default: 3.
+	ProbeNum int
+
+	// ProbeMin and ProbeMax bound the random delay between probes.
+	// Default: 1 and 2 seconds.
+	ProbeMin, ProbeMax time.Duration
+
+	// AnnounceWait is the delay after the last probe before announcing,
+	// during which conflicts are still detected.  Default: 2 seconds.
+	AnnounceWait time.Duration
+
+	// AnnounceNum is the number of announcements sent.  Default: 2.
+	AnnounceNum int
+
+	// AnnounceInterval is the delay between announcements.
+	// Default: 2 seconds.
+	AnnounceInterval time.Duration
+}
+
+// withDefaults returns a copy of o with each zero field replaced by its
+// RFC 5227 default.  o may be nil.
+func (o *DADOptions) withDefaults() DADOptions {
+	var out DADOptions
+	if o != nil {
+		out = *o
+	}
+
+	setDuration := func(d *time.Duration, v time.Duration) {
+		if *d == 0 {
+			*d = v
+		}
+	}
+	setInt := func(n *int, v int) {
+		if *n == 0 {
+			*n = v
+		}
+	}
+
+	setDuration(&out.ProbeWait, 1*time.Second)
+	setInt(&out.ProbeNum, 3)
+	setDuration(&out.ProbeMin, 1*time.Second)
+	setDuration(&out.ProbeMax, 2*time.Second)
+	setDuration(&out.AnnounceWait, 2*time.Second)
+	setInt(&out.AnnounceNum, 2)
+	setDuration(&out.AnnounceInterval, 2*time.Second)
+
+	return out
+}
+
+// A ConflictError is returned by ClaimAddress when other machines are
+// already using, or are probing for, an IPv4 address.  A ConflictError
+// satisfies errors.Is(err, ErrAddressInUse).
+type ConflictError struct {
+	// IP is the IPv4 address which could not be claimed.
+	IP netip.Addr
+
+	// HardwareAddrs are the hardware addresses of the conflicting
+	// machines.
+	HardwareAddrs []net.HardwareAddr
+}
+
+// Error implements error.
+func (e *ConflictError) Error() string {
+	macs := make([]string, 0, len(e.HardwareAddrs))
+	for _, mac := range e.HardwareAddrs {
+		macs = append(macs, mac.String())
+	}
+
+	return fmt.Sprintf("%s: %s used by %s", ErrAddressInUse, e.IP, strings.Join(macs, ", "))
+}
+
+// Is reports whether target is ErrAddressInUse.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrAddressInUse
+}
+
+// ClaimAddress performs duplicate address detection for ip, as described in
+// RFC 5227, section 2.1, and then announces the Client's ownership of ip.
+// Several probes are sent after random delays, and if no other machine
+// replies, several gratuitous ARP requests are sent to announce ip.
+//
+// If opts is nil, the timing and number of probes and announcements use the
+// defaults from RFC 5227, so ClaimAddress may take several seconds to
+// return.  If another machine is using or probing for ip, a *ConflictError
+// carrying the conflicting hardware addresses is returned, and nothing is
+// announced.
+//
+// ClaimAddress overrides any read deadline set on the Client, and clears it
+// before returning.
+func (c *Client) ClaimAddress(ip netip.Addr, opts *DADOptions) error {
+	return c.claimAddress(context.Background(), ip, opts.withDefaults())
+}
+
+// claimAddress implements ClaimAddress, and returns ctx.Err() if ctx is
+// canceled before ip is claimed.
+func (c *Client) claimAddress(ctx context.Context, ip netip.Addr, o DADOptions) error {
+	if err := sleepContext(ctx, jitter(0, o.ProbeWait)); err != nil {
+		return err
+	}
+
+	for i := 0; i < o.ProbeNum; i++ {
+		// Conflicts are detected in the delay between probes, and after
+		// the last probe, until it is time to announce.
+		window := jitter(o.ProbeMin, o.ProbeMax)
+		if i == o.ProbeNum-1 {
+			window = o.AnnounceWait
+		}
+
+		pctx, cancel := context.WithTimeout(ctx, window)
+		macs, err := c.Probe(pctx, ip)
+		cancel()
+
+		switch {
+		case err == ErrAddressInUse:
+			return &ConflictError{
+				IP:            ip,
+				HardwareAddrs: macs,
+			}
+		case err != nil:
+			return err
+		case ctx.Err() != nil:
+			// Probe treats the expiration of the parent context's deadline
+			// the same as the end of the probe window.
+			return ctx.Err()
+		}
+	}
+
+	for i := 0; i < o.AnnounceNum; i++ {
+		if i > 0 {
+			if err := sleepContext(ctx, o.AnnounceInterval); err != nil {
+				return err
+			}
+		}
+
+		if err := c.AnnounceRequest(ip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sleepContext waits for the duration d, or returns ctx.Err() if ctx is
+// canceled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// jitter returns a random duration in the range [min, max).
+func jitter(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+
+	return min + time.Duration(rand.Int63n(int64(max-min)))
+}
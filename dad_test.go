@@ -0,0 +1,113 @@
+package arp
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientClaimAddress(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	opts := &DADOptions{
+		ProbeWait:        time.Millisecond,
+		ProbeMin:         5 * time.Millisecond,
+		ProbeMax:         10 * time.Millisecond,
+		AnnounceWait:     10 * time.Millisecond,
+		AnnounceInterval: time.Millisecond,
+	}
+
+	tests := []struct {
+		desc     string
+		conflict int
+		ops      []Operation
+		macs     []net.HardwareAddr
+	}{
+		{
+			desc: "OK",
+			ops: []Operation{
+				// Probes.
+				OperationRequest, OperationRequest, OperationRequest,
+				// Announcements.
+				OperationRequest, OperationRequest,
+			},
+		},
+		{
+			desc:     "conflict on second probe",
+			conflict: 2,
+			ops:      []Operation{OperationRequest, OperationRequest},
+			macs:     []net.HardwareAddr{mac},
+		},
+	}
+
+	for i, tt := range tests {
+		var probes int
+		p := newReplyPacketConn(func(_ []byte) []timedFrame {
+			probes++
+			if probes != tt.conflict {
+				return nil
+			}
+
+			return []timedFrame{{b: replyFrame(t, mac, ip)}}
+		})
+		c := testClient(t, p)
+
+		err := c.ClaimAddress(ip, opts)
+		if tt.macs == nil {
+			if err != nil {
+				t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+			}
+		} else {
+			if !errors.Is(err, ErrAddressInUse) {
+				t.Fatalf("[%02d] test %q, expected address in use, but got: %v",
+					i, tt.desc, err)
+			}
+
+			var cerr *ConflictError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("[%02d] test %q, expected *ConflictError, but got: %T",
+					i, tt.desc, err)
+			}
+			if want, got := tt.macs, cerr.HardwareAddrs; !reflect.DeepEqual(want, got) {
+				t.Fatalf("[%02d] test %q, unexpected conflicting hardware addresses:\n- want: %v\n-  got: %v",
+					i, tt.desc, want, got)
+			}
+		}
+
+		var ops []Operation
+		for _, b := range p.writes {
+			_, arp, err := ParseEthernetARP(b)
+			if err != nil {
+				t.Fatalf("[%02d] test %q, failed to parse packet: %v", i, tt.desc, err)
+			}
+
+			ops = append(ops, arp.Operation)
+		}
+
+		if want, got := tt.ops, ops; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected packets sent: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestDADOptionsDefaults(t *testing.T) {
+	want := DADOptions{
+		ProbeWait:        1 * time.Second,
+		ProbeNum:         3,
+		ProbeMin:         1 * time.Second,
+		ProbeMax:         2 * time.Second,
+		AnnounceWait:     2 * time.Second,
+		AnnounceNum:      2,
+		AnnounceInterval: 2 * time.Second,
+	}
+
+	var opts *DADOptions
+	if got := opts.withDefaults(); !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected default options:\n- want: %+v\n-  got: %+v", want, got)
+	}
+}
@@ -0,0 +1,73 @@
+//go:build linux
+// +build linux
+
+package arp
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/mdlayher/packet"
+	"golang.org/x/sys/unix"
+)
+
+// DialFanout creates a new Client in the same way as Dial, but adds the
+// Client's raw socket to the PACKET_FANOUT group identified by group.  The
+// kernel load balances incoming ARP packets across every socket in a group,
+// so multiple Clients, each read by its own goroutine or process, can share
+// the work of reading packets on a busy network.  Every socket in a group
+// must be bound to the same interface.
+//
+// Packets are distributed round-robin, so consecutive packets from a single
+// sender may be read by different Clients.
+//
+// cfg is passed to the underlying raw socket in the same way as DialConfig,
+// including the default ARP BPF filter if cfg is nil.
+//
+// DialFanout is only supported on Linux.
+func DialFanout(ifi *net.Interface, group uint16, cfg *packet.Config) (*Client, error) {
+	cfg, err := configOrDefault(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := packet.Listen(ifi, packet.Raw, protocolARP, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := p.SyscallConn()
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	if err := setFanout(rc, group, unix.SetsockoptInt); err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+
+	c, err := New(ifi, p)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// setFanout adds the socket controlled by rc to a PACKET_FANOUT group, using
+// setsockopt to set the socket option, so testing is easier to accomplish.
+func setFanout(rc syscall.RawConn, group uint16, setsockopt func(fd, level, opt, value int) error) error {
+	// The kernel's flow hash has no key for ARP, so PACKET_FANOUT_HASH would
+	// deliver every ARP packet to the same socket.  Distribute packets
+	// round-robin instead.
+	arg := int(group) | unix.PACKET_FANOUT_LB<<16
+
+	var serr error
+	err := rc.Control(func(fd uintptr) {
+		serr = setsockopt(int(fd), unix.SOL_PACKET, unix.PACKET_FANOUT, arg)
+	})
+	if err != nil {
+		return err
+	}
+	return serr
+}
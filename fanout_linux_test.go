@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package arp
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func Test_setFanout(t *testing.T) {
+	var (
+		gotFD, gotLevel, gotOpt, gotValue int
+	)
+
+	rc := &fdRawConn{fd: 10}
+	err := setFanout(rc, 42, func(fd, level, opt, value int) error {
+		gotFD, gotLevel, gotOpt, gotValue = fd, level, opt, value
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 10, gotFD; want != got {
+		t.Fatalf("unexpected file descriptor: %d != %d", want, got)
+	}
+	if want, got := unix.SOL_PACKET, gotLevel; want != got {
+		t.Fatalf("unexpected socket option level: %d != %d", want, got)
+	}
+	if want, got := unix.PACKET_FANOUT, gotOpt; want != got {
+		t.Fatalf("unexpected socket option: %d != %d", want, got)
+	}
+	if want, got := 42|unix.PACKET_FANOUT_LB<<16, gotValue; want != got {
+		t.Fatalf("unexpected socket option value: %#x != %#x", want, got)
+	}
+}
+
+func Test_setFanoutError(t *testing.T) {
+	errSetsockopt := errors.New("test error")
+
+	err := setFanout(&fdRawConn{}, 42, func(_, _, _, _ int) error {
+		return errSetsockopt
+	})
+	if want, got := errSetsockopt, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+}
+
+// fdRawConn is a syscall.RawConn which invokes Control functions with a
+// fixed file descriptor.
+type fdRawConn struct {
+	fd uintptr
+}
+
+func (rc *fdRawConn) Control(fn func(fd uintptr)) error {
+	fn(rc.fd)
+	return nil
+}
+
+func (*fdRawConn) Read(_ func(fd uintptr) bool) error  { return nil }
+func (*fdRawConn) Write(_ func(fd uintptr) bool) error { return nil }
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package arp
+
+import (
+	"errors"
+	"net"
+
+	"github.com/mdlayher/packet"
+)
+
+// errFanoutUnsupported is returned by DialFanout on platforms which do not
+// support PACKET_FANOUT.
+var errFanoutUnsupported = errors.New("PACKET_FANOUT is not supported on this platform")
+
+// DialFanout creates a new Client in the same way as Dial, but adds the
+// Client's raw socket to the PACKET_FANOUT group identified by group.
+//
+// DialFanout is only supported on Linux.
+func DialFanout(ifi *net.Interface, group uint16, cfg *packet.Config) (*Client, error) {
+	return nil, errFanoutUnsupported
+}
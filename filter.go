@@ -0,0 +1,46 @@
+package arp
+
+import (
+	"math"
+
+	"github.com/mdlayher/packet"
+	"golang.org/x/net/bpf"
+)
+
+// arpFilter returns an assembled BPF program which accepts only ethernet
+// frames carrying ARP, so the kernel drops all other frames before they wake
+// the reading process.
+//
+// VLAN tagged frames need no special handling: a socket bound to the ARP
+// EtherType only receives them once the kernel has removed the VLAN tag.
+func arpFilter() ([]bpf.RawInstruction, error) {
+	return bpf.Assemble([]bpf.Instruction{
+		// Load the EtherType.
+		bpf.LoadAbsolute{Off: 12, Size: 2},
+		// Not ARP: reject.
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: protocolARP, SkipFalse: 1},
+		// Accept the entire frame.
+		bpf.RetConstant{Val: math.MaxUint32},
+		// Reject the frame.
+		bpf.RetConstant{Val: 0},
+	})
+}
+
+// defaultConfig returns the packet.Config used when dialing a Client without
+// a caller-provided configuration, which attaches the ARP BPF filter.
+func defaultConfig() (*packet.Config, error) {
+	filter, err := arpFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	return &packet.Config{Filter: filter}, nil
+}
+
+// configOrDefault returns cfg, or the default configuration if cfg is nil.
+func configOrDefault(cfg *packet.Config) (*packet.Config, error) {
+	if cfg != nil {
+		return cfg, nil
+	}
+	return defaultConfig()
+}
@@ -0,0 +1,179 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/mdlayher/ethernet"
+	"golang.org/x/net/bpf"
+)
+
+func Test_arpFilter(t *testing.T) {
+	tests := []struct {
+		desc string
+		b    []byte
+		ok   bool
+	}{
+		{
+			desc: "ARP",
+			b:    etherTypeFrame(t, ethernet.EtherTypeARP),
+			ok:   true,
+		},
+		{
+			desc: "IPv4",
+			b:    etherTypeFrame(t, ethernet.EtherTypeIPv4),
+		},
+		{
+			desc: "IPv6",
+			b:    etherTypeFrame(t, ethernet.EtherTypeIPv6),
+		},
+		{
+			desc: "short frame",
+			b:    []byte{0xff, 0xff},
+		},
+	}
+
+	vm := arpFilterVM(t)
+	for i, tt := range tests {
+		n, err := vm.Run(tt.b)
+		if err != nil {
+			t.Fatalf("[%02d] test %q, failed to run filter: %v", i, tt.desc, err)
+		}
+
+		if want, got := tt.ok, n > 0; want != got {
+			t.Fatalf("[%02d] test %q, unexpected filter result: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+// BenchmarkClientReadFilter demonstrates the effect of the ARP BPF filter
+// on a busy segment where only 1 in 10 frames is ARP.  Without the filter,
+// every frame wakes the process and is discarded by Read in userspace, for
+// 10 wakeups and 9 discarded frames per ARP packet.  With the filter, the 9
+// other frames are dropped before delivery, for a single wakeup per ARP
+// packet.
+func BenchmarkClientReadFilter(b *testing.B) {
+	frames := [][]byte{etherTypeFrame(b, ethernet.EtherTypeARP)}
+	for i := 0; i < 9; i++ {
+		frames = append(frames, etherTypeFrame(b, ethernet.EtherTypeIPv4))
+	}
+
+	tests := []struct {
+		name string
+		vm   *bpf.VM
+	}{
+		{name: "unfiltered"},
+		{name: "filtered", vm: arpFilterVM(b)},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			p := &filterPacketConn{
+				frames: frames,
+				vm:     tt.vm,
+			}
+			c := &Client{p: p}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, _, err := c.Read(); err != nil {
+					b.Fatalf("failed to read: %v", err)
+				}
+			}
+
+			b.ReportMetric(float64(p.wakeups)/float64(b.N), "wakeups/op")
+			b.ReportMetric(float64(p.dropped)/float64(b.N), "dropped/op")
+		})
+	}
+}
+
+// etherTypeFrame builds an ethernet frame carrying an ARP request, with its
+// EtherType set to et.
+func etherTypeFrame(tb testing.TB, et ethernet.EtherType) []byte {
+	tb.Helper()
+
+	p, err := NewPacket(OperationRequest,
+		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}, netip.MustParseAddr("192.168.1.1"),
+		ethernet.Broadcast, netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	f := &ethernet.Frame{
+		Destination: ethernet.Broadcast,
+		Source:      p.SenderHardwareAddr,
+		EtherType:   et,
+		Payload:     pb,
+	}
+
+	b, err := f.MarshalBinary()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return b
+}
+
+// arpFilterVM returns a BPF VM which runs the filter returned by arpFilter.
+func arpFilterVM(tb testing.TB) *bpf.VM {
+	tb.Helper()
+
+	filter, err := arpFilter()
+	if err != nil {
+		tb.Fatalf("failed to assemble filter: %v", err)
+	}
+
+	insns, ok := bpf.Disassemble(filter)
+	if !ok {
+		tb.Fatal("failed to disassemble filter")
+	}
+
+	vm, err := bpf.NewVM(insns)
+	if err != nil {
+		tb.Fatalf("failed to create BPF VM: %v", err)
+	}
+	return vm
+}
+
+// filterPacketConn is a net.PacketConn which endlessly cycles through
+// frames, and drops those rejected by vm, as the kernel would, if vm is set.
+// It counts the frames delivered to ReadFrom, each of which would wake the
+// reading process, and the frames dropped by vm.
+type filterPacketConn struct {
+	frames [][]byte
+	vm     *bpf.VM
+
+	i                int
+	wakeups, dropped int
+
+	noopPacketConn
+}
+
+func (p *filterPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		f := p.frames[p.i%len(p.frames)]
+		p.i++
+
+		if p.vm != nil {
+			n, err := p.vm.Run(f)
+			if err != nil {
+				return 0, nil, err
+			}
+			if n == 0 {
+				p.dropped++
+				continue
+			}
+		}
+
+		p.wakeups++
+		return copy(b, f), nil, nil
+	}
+}
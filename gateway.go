@@ -0,0 +1,100 @@
+package arp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// errNoGateway is returned when no IPv4 default route exists for an
+// interface.
+var errNoGateway = errors.New("no IPv4 default gateway found for interface")
+
+// ResolveGateway reads the IPv4 default gateway for the Client's interface
+// from the system routing table, and performs an ARP request to retrieve
+// its hardware address.  The gateway's IPv4 address and hardware address
+// are returned.
+//
+// ResolveGateway is only supported on Linux.  ResolveGateway overrides any
+// read deadline set on the Client, and clears it before returning.
+func (c *Client) ResolveGateway(ctx context.Context) (netip.Addr, net.HardwareAddr, error) {
+	return c.resolveGateway(ctx, defaultGateway)
+}
+
+// resolveGateway is the internal implementation of ResolveGateway.  It is
+// used to allow an arbitrary gateway lookup function to be used, so testing
+// is easier to accomplish.
+func (c *Client) resolveGateway(ctx context.Context, gateway func(ifi *net.Interface) (netip.Addr, error)) (netip.Addr, net.HardwareAddr, error) {
+	gw, err := gateway(c.ifi)
+	if err != nil {
+		return netip.Addr{}, nil, err
+	}
+
+	arp, _, err := c.resolveContext(ctx, gw)
+	if err != nil {
+		return netip.Addr{}, nil, err
+	}
+
+	return gw, arp.SenderHardwareAddr, nil
+}
+
+// parseRoutes parses the IPv4 routing table in the format of Linux's
+// /proc/net/route, and returns the default gateway for the interface
+// named ifName.  Addresses are decoded using order, which must be the byte
+// order of the host which produced the routing table.
+func parseRoutes(r io.Reader, ifName string, order binary.ByteOrder) (netip.Addr, error) {
+	s := bufio.NewScanner(r)
+
+	// Skip the header line.
+	s.Scan()
+
+	for s.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask ...
+		fields := strings.Fields(s.Text())
+		if len(fields) < 8 || fields[0] != ifName {
+			continue
+		}
+		if fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+
+		gw, err := parseRouteAddr(fields[2], order)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		if gw.IsUnspecified() {
+			continue
+		}
+
+		return gw, nil
+	}
+	if err := s.Err(); err != nil {
+		return netip.Addr{}, err
+	}
+
+	return netip.Addr{}, errNoGateway
+}
+
+// parseRouteAddr parses a hexadecimal IPv4 address from /proc/net/route,
+// which the kernel prints as a 32-bit integer in host byte order, so the
+// address is the in-memory representation of that integer in order.
+func parseRouteAddr(s string, order binary.ByteOrder) (netip.Addr, error) {
+	if len(s) != 8 {
+		return netip.Addr{}, ErrInvalidIP
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	var ip [4]byte
+	order.PutUint32(ip[:], uint32(v))
+	return netip.AddrFrom4(ip), nil
+}
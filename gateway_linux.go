@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package arp
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"os"
+)
+
+// defaultGateway returns the IPv4 default gateway for ifi.
+func defaultGateway(ifi *net.Interface) (netip.Addr, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer f.Close()
+
+	return parseRoutes(f, ifi.Name, binary.NativeEndian)
+}
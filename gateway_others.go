@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package arp
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+)
+
+// errGatewayUnsupported is returned by ResolveGateway on platforms where
+// the system routing table cannot be read.
+var errGatewayUnsupported = errors.New("reading the default gateway is not supported on this platform")
+
+// defaultGateway returns the IPv4 default gateway for ifi.
+func defaultGateway(ifi *net.Interface) (netip.Addr, error) {
+	return netip.Addr{}, errGatewayUnsupported
+}
@@ -0,0 +1,126 @@
+package arp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClientResolveGateway(t *testing.T) {
+	gw := netip.MustParseAddr("192.168.1.254")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+		return []timedFrame{
+			{b: replyFrame(t, net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}, netip.MustParseAddr("192.168.1.20"))},
+			{b: replyFrame(t, mac, gw)},
+		}
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ip, got, err := c.resolveGateway(ctx, func(_ *net.Interface) (netip.Addr, error) {
+		return gw, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := gw, ip; want != got {
+		t.Fatalf("unexpected gateway IP: %v != %v", want, got)
+	}
+	if want := mac; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected gateway hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func Test_parseRoutes(t *testing.T) {
+	const routes = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth1	00000000	0100000A	0003	0	0	100	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+eth0	00000000	FE01A8C0	0003	0	0	100	00000000	0	0	0
+`
+
+	tests := []struct {
+		desc string
+		ifi  string
+		gw   netip.Addr
+		err  error
+	}{
+		{
+			desc: "eth0",
+			ifi:  "eth0",
+			gw:   netip.MustParseAddr("192.168.1.254"),
+		},
+		{
+			desc: "eth1",
+			ifi:  "eth1",
+			gw:   netip.MustParseAddr("10.0.0.1"),
+		},
+		{
+			desc: "no default route",
+			ifi:  "eth2",
+			err:  errNoGateway,
+		},
+	}
+
+	for i, tt := range tests {
+		gw, err := parseRoutes(strings.NewReader(routes), tt.ifi, binary.LittleEndian)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.gw, gw; want != got {
+			t.Fatalf("[%02d] test %q, unexpected gateway: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func Test_parseRouteAddr(t *testing.T) {
+	tests := []struct {
+		desc  string
+		s     string
+		order binary.ByteOrder
+		ip    netip.Addr
+		err   error
+	}{
+		{
+			desc: "short",
+			s:    "0101A8",
+			err:  ErrInvalidIP,
+		},
+		{
+			desc:  "little endian",
+			s:     "FE01A8C0",
+			order: binary.LittleEndian,
+			ip:    netip.MustParseAddr("192.168.1.254"),
+		},
+		{
+			desc:  "big endian",
+			s:     "C0A801FE",
+			order: binary.BigEndian,
+			ip:    netip.MustParseAddr("192.168.1.254"),
+		},
+	}
+
+	for i, tt := range tests {
+		ip, err := parseRouteAddr(tt.s, tt.order)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.ip, ip; want != got {
+			t.Fatalf("[%02d] test %q, unexpected IP: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package arp
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// InterfaceHardwareType returns the ARP hardware type of the network link
+// used by ifi, such as HardwareTypeInfiniband for IP over InfiniBand.
+//
+// On Linux, the link type is read from /sys/class/net/<ifi.Name>/type.
+// Otherwise, or if the link type is unknown, the hardware type is inferred
+// from the length of ifi's hardware address: 20 bytes indicates InfiniBand,
+// and anything else is assumed to be Ethernet.
+func InterfaceHardwareType(ifi *net.Interface) HardwareType {
+	if ifi.Name != "" {
+		if t, ok := linkHardwareType(ifi.Name); ok {
+			return t
+		}
+	}
+
+	// IP over InfiniBand hardware addresses are 20 bytes, per RFC 4391.
+	if len(ifi.HardwareAddr) == 20 {
+		return HardwareTypeInfiniband
+	}
+	return HardwareTypeEthernet
+}
+
+// parseLinkType parses a Linux ARPHRD_* link type, as found in
+// /sys/class/net/<interface>/type, into an ARP hardware type.
+func parseLinkType(s string) (HardwareType, bool) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, false
+	}
+
+	// Link types below 256 are defined to match the ARP hardware types
+	// assigned by IANA.  Larger values, such as ARPHRD_LOOPBACK, identify
+	// links which have no ARP hardware type.
+	if v <= 0 || v >= 256 {
+		return 0, false
+	}
+
+	return HardwareType(v), true
+}
@@ -0,0 +1,17 @@
+//go:build linux
+// +build linux
+
+package arp
+
+import "os"
+
+// linkHardwareType returns the ARP hardware type of the interface named
+// name, using its link type in sysfs.
+func linkHardwareType(name string) (HardwareType, bool) {
+	b, err := os.ReadFile("/sys/class/net/" + name + "/type")
+	if err != nil {
+		return 0, false
+	}
+
+	return parseLinkType(string(b))
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package arp
+
+// linkHardwareType reports that link types are unavailable on platforms
+// other than Linux, so the hardware type is inferred instead.
+func linkHardwareType(_ string) (HardwareType, bool) {
+	return 0, false
+}
@@ -0,0 +1,100 @@
+package arp
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func Test_parseLinkType(t *testing.T) {
+	var tests = []struct {
+		desc string
+		s    string
+		t    HardwareType
+		ok   bool
+	}{
+		{
+			desc: "not a number",
+			s:    "ether\n",
+		},
+		{
+			desc: "loopback",
+			s:    "772\n",
+		},
+		{
+			desc: "ethernet",
+			s:    "1\n",
+			t:    HardwareTypeEthernet,
+			ok:   true,
+		},
+		{
+			desc: "infiniband",
+			s:    "32\n",
+			t:    HardwareTypeInfiniband,
+			ok:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		typ, ok := parseLinkType(tt.s)
+		if want, got := tt.ok, ok; want != got {
+			t.Fatalf("[%02d] test %q, unexpected ok: %v != %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.t, typ; want != got {
+			t.Fatalf("[%02d] test %q, unexpected HardwareType: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestInterfaceHardwareTypeHardwareAddrLength(t *testing.T) {
+	var tests = []struct {
+		desc string
+		mac  net.HardwareAddr
+		t    HardwareType
+	}{
+		{
+			desc: "ethernet",
+			mac:  net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			t:    HardwareTypeEthernet,
+		},
+		{
+			desc: "IP over InfiniBand",
+			mac:  net.HardwareAddr(bytes.Repeat([]byte{0xaa}, 20)),
+			t:    HardwareTypeInfiniband,
+		},
+	}
+
+	for i, tt := range tests {
+		got := InterfaceHardwareType(&net.Interface{HardwareAddr: tt.mac})
+		if want := tt.t; want != got {
+			t.Fatalf("[%02d] test %q, unexpected HardwareType: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestClientHardwareTypeInfiniband(t *testing.T) {
+	c, err := newClient(&net.Interface{
+		HardwareAddr: net.HardwareAddr(bytes.Repeat([]byte{0xaa}, 20)),
+	}, &noopPacketConn{}, []netip.Prefix{netip.MustParsePrefix("192.168.1.1/24")})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if want, got := HardwareTypeInfiniband, c.HardwareType(); want != got {
+		t.Fatalf("unexpected Client HardwareType: %v != %v", want, got)
+	}
+
+	p, err := c.buildRequest(netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if want, got := HardwareTypeInfiniband, p.HardwareType; want != got {
+		t.Fatalf("unexpected request HardwareType: %v != %v", want, got)
+	}
+}
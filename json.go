@@ -0,0 +1,58 @@
+package arp
+
+import (
+	"encoding/json"
+	"net/netip"
+)
+
+// MarshalJSON implements json.Marshaler.
+//
+// The JSON object always contains the following fields, in this order:
+// "hardwareType", "protocolType", "hardwareAddrLength", "ipLength",
+// "operation", "senderHardwareAddr", "senderIP", "targetHardwareAddr", and
+// "targetIP".  The hardware type, protocol type, and operation are objects
+// which contain both the raw numeric "value" and a human-readable "name",
+// so consumers may rely on either.
+func (p *Packet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonPacket{
+		HardwareType: jsonNamed{
+			Value: uint16(p.HardwareType),
+			Name:  p.HardwareType.String(),
+		},
+		ProtocolType: jsonNamed{
+			Value: p.ProtocolType,
+			Name:  protocolString(p.ProtocolType),
+		},
+		HardwareAddrLength: p.HardwareAddrLength,
+		IPLength:           p.IPLength,
+		Operation: jsonNamed{
+			Value: uint16(p.Operation),
+			Name:  operationName(p.Operation),
+		},
+		SenderHardwareAddr: p.SenderHardwareAddr.String(),
+		SenderIP:           p.SenderIP,
+		TargetHardwareAddr: p.TargetHardwareAddr.String(),
+		TargetIP:           p.TargetIP,
+	})
+}
+
+// A jsonPacket is the JSON representation of a Packet.  encoding/json emits
+// struct fields in declaration order, so the order of these fields must not
+// change.
+type jsonPacket struct {
+	HardwareType       jsonNamed  `json:"hardwareType"`
+	ProtocolType       jsonNamed  `json:"protocolType"`
+	HardwareAddrLength uint8      `json:"hardwareAddrLength"`
+	IPLength           uint8      `json:"ipLength"`
+	Operation          jsonNamed  `json:"operation"`
+	SenderHardwareAddr string     `json:"senderHardwareAddr"`
+	SenderIP           netip.Addr `json:"senderIP"`
+	TargetHardwareAddr string     `json:"targetHardwareAddr"`
+	TargetIP           netip.Addr `json:"targetIP"`
+}
+
+// A jsonNamed is the JSON representation of a numeric value with a name.
+type jsonNamed struct {
+	Value uint16 `json:"value"`
+	Name  string `json:"name"`
+}
@@ -0,0 +1,40 @@
+package arp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/mdlayher/ethernet"
+)
+
+func TestPacketMarshalJSON(t *testing.T) {
+	p := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationReply,
+		SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		SenderIP:           netip.MustParseAddr("192.168.1.10"),
+		TargetHardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		TargetIP:           netip.MustParseAddr("192.168.1.1"),
+	}
+
+	b, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile("testdata/packet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := bytes.TrimSpace(golden), b; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected Packet JSON:\n- want: %s\n-  got: %s", want, got)
+	}
+}
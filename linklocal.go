@@ -0,0 +1,58 @@
+package arp
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/netip"
+)
+
+// linkLocalMaxAttempts is the maximum number of IPv4 link-local addresses
+// tried by ConfigureLinkLocal, based on MAX_CONFLICTS from RFC 3927.
+const linkLocalMaxAttempts = 10
+
+// ConfigureLinkLocal selects an IPv4 link-local address for the Client's
+// interface, as described in RFC 3927.  A random address in 169.254.0.0/16
+// is chosen and claimed using ClaimAddress, with the default probe and
+// announcement schedule, whose constants RFC 3927 shares with RFC 5227.  If
+// another machine is already using the address, a new address is chosen and
+// the process is repeated, up to 10 times.
+//
+// ConfigureLinkLocal does not assign the chosen address to the interface;
+// the caller is responsible for doing so.
+//
+// If no address could be claimed, ErrAddressInUse is returned.  If ctx is
+// canceled first, ctx.Err() is returned.
+func (c *Client) ConfigureLinkLocal(ctx context.Context) (netip.Addr, error) {
+	return c.configureLinkLocal(ctx, nil, rand.Intn)
+}
+
+// configureLinkLocal implements ConfigureLinkLocal, using opts to claim each
+// address and intn to choose random addresses, so testing is easier to
+// accomplish.
+func (c *Client) configureLinkLocal(ctx context.Context, opts *DADOptions, intn func(n int) int) (netip.Addr, error) {
+	o := opts.withDefaults()
+
+	for i := 0; i < linkLocalMaxAttempts; i++ {
+		ip := randomLinkLocal(intn)
+
+		err := c.claimAddress(ctx, ip, o)
+		switch {
+		case errors.Is(err, ErrAddressInUse):
+			continue
+		case err != nil:
+			return netip.Addr{}, err
+		}
+
+		return ip, nil
+	}
+
+	return netip.Addr{}, ErrAddressInUse
+}
+
+// randomLinkLocal chooses a random IPv4 link-local address using intn.  The
+// first and last 256 addresses in 169.254.0.0/16 are reserved by RFC 3927.
+func randomLinkLocal(intn func(n int) int) netip.Addr {
+	n := 256 + intn(254*256)
+	return netip.AddrFrom4([4]byte{169, 254, byte(n >> 8), byte(n)})
+}
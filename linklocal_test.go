@@ -0,0 +1,162 @@
+package arp
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/packet"
+)
+
+func TestClientConfigureLinkLocalConflict(t *testing.T) {
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	// Reject the first candidate address by claiming it from another machine.
+	var probes int
+	p := newReplyPacketConn(func(b []byte) []timedFrame {
+		arp, _, err := parsePacket(b)
+		if err != nil {
+			panic(err)
+		}
+		if !arp.SenderIP.IsUnspecified() {
+			// Not a probe.
+			return nil
+		}
+
+		probes++
+		if probes > 1 {
+			return nil
+		}
+
+		return []timedFrame{{b: replyFrame(t, mac, arp.TargetIP)}}
+	})
+	c := testClient(t, p)
+
+	// Choose sequential candidate addresses.
+	var n int
+	intn := func(_ int) int {
+		n++
+		return n
+	}
+
+	ip, err := c.configureLinkLocal(context.Background(), testDADOptions, intn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := netip.MustParseAddr("169.254.1.2"), ip; want != got {
+		t.Fatalf("unexpected link-local address: %v != %v", want, got)
+	}
+
+	// Expect a conflicting probe for the first address, then three probes
+	// and two announcements for the second.
+	if want, got := 6, len(p.writes); want != got {
+		t.Fatalf("unexpected number of writes: %d != %d", want, got)
+	}
+
+	for i, b := range p.writes[4:] {
+		announce, _, err := parsePacket(b)
+		if err != nil {
+			t.Fatalf("failed to parse announcement: %v", err)
+		}
+		if announce.SenderIP != ip || announce.TargetIP != ip {
+			t.Fatalf("[%02d] unexpected announcement: %v", i, announce)
+		}
+	}
+}
+
+func TestClientConfigureLinkLocalExhausted(t *testing.T) {
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	// Reject every candidate address.
+	c := testClient(t, newReplyPacketConn(func(b []byte) []timedFrame {
+		arp, _, err := parsePacket(b)
+		if err != nil {
+			panic(err)
+		}
+
+		return []timedFrame{{b: replyFrame(t, mac, arp.TargetIP)}}
+	}))
+
+	_, err := c.configureLinkLocal(context.Background(), testDADOptions, func(n int) int { return 0 })
+	if want, got := ErrAddressInUse, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+}
+
+func TestClientConfigureLinkLocalCanceled(t *testing.T) {
+	c := testClient(t, newReplyPacketConn(nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := c.configureLinkLocal(ctx, testDADOptions, func(n int) int { return 0 })
+	if want, got := context.Canceled, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+}
+
+func TestClientConfigureLinkLocalNoIPv4Address(t *testing.T) {
+	// An interface index which does not exist has no addresses, as with an
+	// interface which has just come up and needs a link-local address.
+	ifi := &net.Interface{
+		Index:        1<<31 - 1,
+		Name:         "arptest0",
+		HardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+	}
+
+	p := newReplyPacketConn(nil)
+	c, err := dialConfig(ifi, nil, func(_ *net.Interface, _ *packet.Config) (net.PacketConn, error) {
+		return p, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to dial interface without IPv4 address: %v", err)
+	}
+
+	ip, err := c.configureLinkLocal(context.Background(), testDADOptions, func(n int) int { return 0 })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := netip.MustParseAddr("169.254.1.0"), ip; want != got {
+		t.Fatalf("unexpected link-local address: %v != %v", want, got)
+	}
+
+	// Expect three probes and two announcements.
+	if want, got := 5, len(p.writes); want != got {
+		t.Fatalf("unexpected number of writes: %d != %d", want, got)
+	}
+
+	// Requests need a sender IPv4 address, which the interface lacks.
+	if want, got := errNoIPv4Addr, c.Request(ip); want != got {
+		t.Fatalf("unexpected request error: %v != %v", want, got)
+	}
+}
+
+// testDADOptions are DADOptions with short delays, for use in tests.
+var testDADOptions = &DADOptions{
+	ProbeWait:        time.Millisecond,
+	ProbeMin:         5 * time.Millisecond,
+	ProbeMax:         10 * time.Millisecond,
+	AnnounceWait:     10 * time.Millisecond,
+	AnnounceInterval: time.Millisecond,
+}
+
+func Test_randomLinkLocal(t *testing.T) {
+	tests := []struct {
+		n  int
+		ip netip.Addr
+	}{
+		{n: 0, ip: netip.MustParseAddr("169.254.1.0")},
+		{n: 254*256 - 1, ip: netip.MustParseAddr("169.254.254.255")},
+	}
+
+	for i, tt := range tests {
+		ip := randomLinkLocal(func(_ int) int { return tt.n })
+		if want, got := tt.ip, ip; want != got {
+			t.Fatalf("[%02d] unexpected link-local address: %v != %v", i, want, got)
+		}
+	}
+}
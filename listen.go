@@ -0,0 +1,38 @@
+package arp
+
+import "time"
+
+// Listen passively reads every ARP packet seen on the Client's interface,
+// without sending anything, and delivers each packet on the returned
+// channel.  Unlike Resolve, Listen does not filter packets by operation,
+// address, or destination, so it is suitable for building arpwatch-style
+// monitoring and intrusion detection tools.
+//
+// Frames which cannot be parsed as ARP packets are skipped, so a single
+// malformed frame does not end monitoring.  The channel is closed when
+// reading from the Client's socket fails, typically because the Client was
+// closed.  Callers must receive from the channel until it is closed, and
+// must not read from the Client by other means meanwhile.
+//
+// Listen clears any read deadline set on the Client, and returns an error
+// if it cannot do so.
+func (c *Client) Listen() (<-chan *Packet, error) {
+	if err := c.SetReadDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+
+	packets := make(chan *Packet)
+	go func() {
+		defer close(packets)
+		for {
+			p, _, err := c.read(true)
+			if err != nil {
+				return
+			}
+
+			packets <- p
+		}
+	}()
+
+	return packets, nil
+}
@@ -0,0 +1,97 @@
+package arp
+
+import (
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/mdlayher/ethernet"
+)
+
+func TestClientListen(t *testing.T) {
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	other := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+
+	// Packets not addressed to the Client, and of every operation, must all
+	// be delivered.
+	request, err := NewPacket(OperationRequest, mac, netip.MustParseAddr("192.168.1.10"),
+		ethernet.Broadcast, netip.MustParseAddr("192.168.1.20"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := NewPacket(OperationReply, other, netip.MustParseAddr("192.168.1.20"),
+		mac, netip.MustParseAddr("192.168.1.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A malformed frame must be skipped without ending monitoring, and the
+	// channel closed once the socket returns an error.
+	c := testClient(t, &framesPacketConn{
+		frames: [][]byte{
+			malformedFrame(t),
+			packetFrame(t, request, ethernet.Broadcast),
+			malformedFrame(t),
+			packetFrame(t, reply, mac),
+		},
+	})
+
+	packets, err := c.Listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*Packet
+	for p := range packets {
+		got = append(got, p)
+	}
+
+	want := []*Packet{request, reply}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of packets: %d != %d", len(want), len(got))
+	}
+	for i := range want {
+		if !want[i].Equal(got[i]) {
+			t.Fatalf("[%02d] unexpected Packet:\n- want: %v\n-  got: %v", i, want[i], got[i])
+		}
+	}
+}
+
+// malformedFrame builds an ethernet frame with the ARP EtherType, but a
+// truncated ARP packet.
+func malformedFrame(t *testing.T) []byte {
+	t.Helper()
+
+	f := &ethernet.Frame{
+		Destination: ethernet.Broadcast,
+		Source:      net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		EtherType:   ethernet.EtherTypeARP,
+		Payload:     []byte{0x00, 0x01, 0x08, 0x00},
+	}
+
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("failed to marshal frame: %v", err)
+	}
+
+	return b
+}
+
+// framesPacketConn is a net.PacketConn which returns each of its frames in
+// turn from its ReadFrom method, and then io.EOF.
+type framesPacketConn struct {
+	frames [][]byte
+
+	noopPacketConn
+}
+
+func (p *framesPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if len(p.frames) == 0 {
+		return 0, nil, io.EOF
+	}
+
+	f := p.frames[0]
+	p.frames = p.frames[1:]
+	return copy(b, f), nil, nil
+}
@@ -0,0 +1,65 @@
+package arp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// Monitor periodically performs ARP requests for each IPv4 address in ips,
+// turning ARP into a lightweight liveness check for machines on the local
+// network.  Each address is given up to interval to reply, and rounds of
+// requests are separated by interval.
+//
+// onChange is called with the state of each address the first time it is
+// checked, and afterwards whenever the address transitions between
+// reachable (up) and unreachable, or replies from a different hardware
+// address.  mac is nil when up is false.
+//
+// Monitor blocks until ctx is canceled, and then returns ctx.Err().  Monitor
+// overrides any read deadline set on the Client, and clears it before
+// returning.
+func (c *Client) Monitor(ctx context.Context, ips []netip.Addr, interval time.Duration, onChange func(ip netip.Addr, up bool, mac net.HardwareAddr)) error {
+	type state struct {
+		up  bool
+		mac net.HardwareAddr
+	}
+
+	states := make(map[netip.Addr]*state, len(ips))
+	for {
+		for _, ip := range ips {
+			rctx, cancel := context.WithTimeout(ctx, interval)
+			arp, _, err := c.resolveContext(rctx, ip)
+			cancel()
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var mac net.HardwareAddr
+			switch {
+			case err == nil:
+				mac = arp.SenderHardwareAddr
+			case err != context.DeadlineExceeded:
+				return err
+			}
+
+			up := mac != nil
+			s, ok := states[ip]
+			if ok && s.up == up && bytes.Equal(s.mac, mac) {
+				continue
+			}
+
+			states[ip] = &state{up: up, mac: mac}
+			onChange(ip, up, mac)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package arp
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientMonitor(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	// The host replies to the first two requests, and then goes down.
+	var requests int
+	c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+		requests++
+		if requests > 2 {
+			return nil
+		}
+
+		return []timedFrame{{b: replyFrame(t, mac, ip)}}
+	}))
+
+	type event struct {
+		ip  netip.Addr
+		up  bool
+		mac net.HardwareAddr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var events []event
+	err := c.Monitor(ctx, []netip.Addr{ip}, 20*time.Millisecond, func(ip netip.Addr, up bool, mac net.HardwareAddr) {
+		events = append(events, event{ip: ip, up: up, mac: mac})
+		if len(events) == 2 {
+			cancel()
+		}
+	})
+	if want, got := context.Canceled, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+
+	want := []event{
+		{ip: ip, up: true, mac: mac},
+		{ip: ip, up: false},
+	}
+	if got := events; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected events:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if requests < 3 {
+		t.Fatalf("expected at least 3 requests, got %d", requests)
+	}
+}
@@ -3,10 +3,13 @@ package arp
 import (
 	"bytes"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/netip"
+	"strings"
 
 	"github.com/mdlayher/ethernet"
 )
@@ -23,6 +26,19 @@ var (
 	// errInvalidARPPacket is returned when an ethernet frame does not
 	// indicate that an ARP packet is contained in its payload.
 	errInvalidARPPacket = errors.New("invalid ARP packet")
+
+	// Errors returned by Packet.Sane for nonsensical ARP replies.
+	errZeroSenderHardwareAddr = errors.New("zero sender hardware address in reply")
+	errZeroTargetHardwareAddr = errors.New("zero target hardware address in reply")
+	errUnspecifiedSenderIP    = errors.New("unspecified sender IP address in reply")
+
+	// infinibandBroadcast is the IP over Infiniband (IPoIB) broadcast
+	// hardware address for the default partition, as described in RFC 4391.
+	infinibandBroadcast = net.HardwareAddr{
+		0x00, 0xff, 0xff, 0xff,
+		0xff, 0x12, 0x40, 0x1b, 0xff, 0xff, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+	}
 )
 
 //go:generate stringer -output=string.go -type=Operation
@@ -34,13 +50,43 @@ type Operation uint16
 const (
 	OperationRequest Operation = 1
 	OperationReply   Operation = 2
+
+	// Reverse ARP operations, as described in RFC 903.
+	OperationRARPRequest Operation = 3
+	OperationRARPReply   Operation = 4
+
+	// Inverse ARP operations, as described in RFC 2390.
+	OperationInARPRequest Operation = 8
+	OperationInARPReply   Operation = 9
+)
+
+//go:generate stringer -output=hardwaretype_string.go -type=HardwareType -trimprefix=HardwareType
+
+// A HardwareType is an IANA-assigned ARP hardware type, which indicates the
+// type of network link an ARP packet is sent over.
+type HardwareType uint16
+
+// HardwareType constants for common network link types.
+//
+// A full list of IANA-assigned values may be found here:
+// https://www.iana.org/assignments/arp-parameters/arp-parameters.xhtml.
+const (
+	HardwareTypeEthernet     HardwareType = 1
+	HardwareTypeIEEE802      HardwareType = 6
+	HardwareTypeARCNET       HardwareType = 7
+	HardwareTypeFrameRelay   HardwareType = 15
+	HardwareTypeATM          HardwareType = 16
+	HardwareTypeHDLC         HardwareType = 17
+	HardwareTypeFibreChannel HardwareType = 18
+	HardwareTypeSerial       HardwareType = 20
+	HardwareTypeInfiniband   HardwareType = 32
 )
 
 // A Packet is a raw ARP packet, as described in RFC 826.
 type Packet struct {
 	// HardwareType specifies an IANA-assigned hardware type, as described
 	// in RFC 826.
-	HardwareType uint16
+	HardwareType HardwareType
 
 	// ProtocolType specifies the internetwork protocol for which the ARP
 	// request is intended.  Typically, this is the IPv4 EtherType.
@@ -82,6 +128,18 @@ type Packet struct {
 // If either IP address is not an IPv4 address, or there is a length mismatch
 // between the two, ErrInvalidIP is returned.
 func NewPacket(op Operation, srcHW net.HardwareAddr, srcIP netip.Addr, dstHW net.HardwareAddr, dstIP netip.Addr) (*Packet, error) {
+	return NewPacketProtocol(op, uint16(ethernet.EtherTypeIPv4), srcHW, srcIP, dstHW, dstIP)
+}
+
+// NewPacketProtocol creates a new Packet in the same way as NewPacket, but
+// with the protocol type set to protocol, so packets may be built for
+// protocols other than IPv4.
+//
+// If protocol is the IPv4 EtherType, both protocol addresses must be IPv4
+// addresses, as with NewPacket.  Otherwise, both protocol addresses must be
+// valid and of the same length, which determines the packet's IPLength, or
+// ErrInvalidIP is returned.
+func NewPacketProtocol(op Operation, protocol uint16, srcHW net.HardwareAddr, srcIP netip.Addr, dstHW net.HardwareAddr, dstIP netip.Addr) (*Packet, error) {
 	// Validate hardware addresses for minimum length, and matching length
 	if len(srcHW) < 6 {
 		return nil, ErrInvalidHardwareAddr
@@ -93,27 +151,29 @@ func NewPacket(op Operation, srcHW net.HardwareAddr, srcIP netip.Addr, dstHW net
 		return nil, ErrInvalidHardwareAddr
 	}
 
-	// Validate IP addresses to ensure they are IPv4 addresses, and
-	// correct length
-	var invalidIP netip.Addr
-	if !srcIP.IsValid() || !srcIP.Is4() {
-		return nil, ErrInvalidIP
-	}
-	if !dstIP.Is4() || dstIP == invalidIP {
-		return nil, ErrInvalidIP
+	// Validate protocol addresses to ensure they are IPv4 addresses when
+	// the protocol is IPv4, and have a matching length otherwise
+	if protocol == uint16(ethernet.EtherTypeIPv4) {
+		if !srcIP.Is4() || !dstIP.Is4() {
+			return nil, ErrInvalidIP
+		}
+	} else {
+		if !srcIP.IsValid() || !dstIP.IsValid() || srcIP.BitLen() != dstIP.BitLen() {
+			return nil, ErrInvalidIP
+		}
 	}
 
 	return &Packet{
-		// There is no Go-native way to detect hardware type of a network
-		// interface, so default to 1 (ethernet 10Mb) for now
-		HardwareType: 1,
+		// A Packet has no network interface to detect the hardware type
+		// from, so default to ethernet.  A Client sets the hardware type
+		// of its interface, as reported by InterfaceHardwareType.
+		HardwareType: HardwareTypeEthernet,
 
-		// Default to EtherType for IPv4
-		ProtocolType: uint16(ethernet.EtherTypeIPv4),
+		ProtocolType: protocol,
 
 		// Populate other fields using input data
 		HardwareAddrLength: uint8(len(srcHW)),
-		IPLength:           uint8(4),
+		IPLength:           uint8(srcIP.BitLen() / 8),
 		Operation:          op,
 		SenderHardwareAddr: srcHW,
 		SenderIP:           srcIP,
@@ -122,10 +182,179 @@ func NewPacket(op Operation, srcHW net.HardwareAddr, srcIP netip.Addr, dstHW net
 	}, nil
 }
 
+// IsRequest reports whether p is an ARP request.
+func (p *Packet) IsRequest() bool {
+	return p.Operation == OperationRequest
+}
+
+// IsReply reports whether p is an ARP reply.
+func (p *Packet) IsReply() bool {
+	return p.Operation == OperationReply
+}
+
+// Sane performs advisory checks for obviously bogus packets, which ARP
+// cannot otherwise detect because it has no checksum.  An ARP reply must
+// have non-zero sender and target hardware addresses, and a specified sender
+// IP address.  If p fails any check, an error describing the problem is
+// returned.
+//
+// Sane is not enforced by UnmarshalBinary; callers such as monitors may use
+// it to filter packets.
+func (p *Packet) Sane() error {
+	if !p.IsReply() {
+		return nil
+	}
+
+	switch {
+	case isZero(p.SenderHardwareAddr):
+		return errZeroSenderHardwareAddr
+	case isZero(p.TargetHardwareAddr):
+		return errZeroTargetHardwareAddr
+	case !p.SenderIP.IsValid() || p.SenderIP.IsUnspecified():
+		return errUnspecifiedSenderIP
+	}
+
+	return nil
+}
+
+// Equal reports whether p and other contain the same ARP packet.  Hardware
+// addresses are compared by value, and IPv4-mapped IPv6 addresses are
+// treated as equal to their IPv4 form.  Two nil Packets are equal.
+func (p *Packet) Equal(other *Packet) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+
+	return p.HardwareType == other.HardwareType &&
+		p.ProtocolType == other.ProtocolType &&
+		p.HardwareAddrLength == other.HardwareAddrLength &&
+		p.IPLength == other.IPLength &&
+		p.Operation == other.Operation &&
+		bytes.Equal(p.SenderHardwareAddr, other.SenderHardwareAddr) &&
+		p.SenderIP.Unmap() == other.SenderIP.Unmap() &&
+		bytes.Equal(p.TargetHardwareAddr, other.TargetHardwareAddr) &&
+		p.TargetIP.Unmap() == other.TargetIP.Unmap()
+}
+
+// Clone returns a deep copy of p, which does not alias any of p's hardware
+// addresses.  Clone should be used to retain a Packet whose fields alias a
+// transient buffer, such as one returned by Client.ReceiveInto.
+func (p *Packet) Clone() *Packet {
+	if p == nil {
+		return nil
+	}
+
+	c := *p
+	c.SenderHardwareAddr = cloneHardwareAddr(p.SenderHardwareAddr)
+	c.TargetHardwareAddr = cloneHardwareAddr(p.TargetHardwareAddr)
+	return &c
+}
+
+// cloneHardwareAddr returns a copy of mac, or nil if mac is nil.
+func cloneHardwareAddr(mac net.HardwareAddr) net.HardwareAddr {
+	if mac == nil {
+		return nil
+	}
+
+	return append(net.HardwareAddr(nil), mac...)
+}
+
+// isZero reports whether every byte of mac is zero.
+func isZero(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a human-readable representation of a Packet, in a format
+// such as:
+//
+//	ARP reply 192.168.1.10 (aa:bb:cc:dd:ee:ff) -> 192.168.1.1 (de:ad:be:ef:de:ad) [Ethernet, IPv4]
+//
+// The hardware and protocol types are decoded into names, such as "Ethernet"
+// and "IPv4", where they are known.  Gratuitous packets, whose sender and
+// target IPv4 addresses are equal, and RFC 5227 probes, whose sender IPv4
+// address is unspecified, are noted after the operation.
+func (p *Packet) String() string {
+	var op string
+	switch p.Operation {
+	case OperationRARPRequest:
+		op = "RARP request"
+	case OperationRARPReply:
+		op = "RARP reply"
+	case OperationInARPRequest:
+		op = "InARP request"
+	case OperationInARPReply:
+		op = "InARP reply"
+	default:
+		op = operationName(p.Operation)
+		if !strings.HasPrefix(op, "Operation(") {
+			op = strings.ToLower(op)
+		}
+	}
+
+	switch {
+	case p.IsRequest() && p.SenderIP.IsUnspecified():
+		op += " (probe)"
+	case p.SenderIP.IsValid() && !p.SenderIP.IsUnspecified() && p.SenderIP == p.TargetIP:
+		op += " (gratuitous)"
+	}
+
+	return fmt.Sprintf("ARP %s %s (%s) -> %s (%s) [%s, %s]",
+		op,
+		p.SenderIP, p.SenderHardwareAddr,
+		p.TargetIP, p.TargetHardwareAddr,
+		p.HardwareType, protocolString(p.ProtocolType),
+	)
+}
+
+// operationName returns the name of op without its "Operation" prefix, or
+// its generated string representation if op is unknown.
+func operationName(op Operation) string {
+	s := op.String()
+	if strings.HasPrefix(s, "Operation(") {
+		return s
+	}
+	return strings.TrimPrefix(s, "Operation")
+}
+
+// protocolString returns the name of an ARP protocol type, which is an
+// EtherType value, or its hexadecimal value if the name is unknown.
+func protocolString(pt uint16) string {
+	s := ethernet.EtherType(pt).String()
+	if strings.HasPrefix(s, "EtherType(") {
+		return fmt.Sprintf("0x%04x", pt)
+	}
+	return strings.TrimPrefix(s, "EtherType")
+}
+
 // MarshalBinary allocates a byte slice containing the data from a Packet.
+// The result is exactly the ARP packet, 8 + 2*HardwareAddrLength +
+// 2*IPLength bytes in length, with no padding.  Padding to the minimum
+// ethernet frame length is applied only when a frame is built for sending,
+// such as by Client.WriteTo.
 //
-// MarshalBinary never returns an error.
+// Hardware addresses shorter than p.HardwareAddrLength are zero-padded to
+// that length.  If either hardware address is longer than
+// p.HardwareAddrLength, ErrInvalidHardwareAddr is returned.
 func (p *Packet) MarshalBinary() ([]byte, error) {
+	b := make([]byte, p.length())
+	if _, err := p.MarshalTo(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// MarshalTo marshals a Packet into b in the same way as MarshalBinary, and
+// returns the number of bytes written.  MarshalTo does not allocate when
+// the hardware addresses match p.HardwareAddrLength, so a single buffer may
+// be reused to marshal many Packets.  If b is too small to hold the Packet,
+// io.ErrShortBuffer is returned.
+func (p *Packet) MarshalTo(b []byte) (int, error) {
 	// 2 bytes: hardware type
 	// 2 bytes: protocol type
 	// 1 byte : hardware address length
@@ -139,11 +368,29 @@ func (p *Packet) MarshalBinary() ([]byte, error) {
 	// Though an IPv4 address should always 4 bytes, go-fuzz
 	// very quickly created several crasher scenarios which
 	// indicated that these values can lie.
-	b := make([]byte, 2+2+1+1+2+(p.IPLength*2)+(p.HardwareAddrLength*2))
+	l := p.length()
+	if len(b) < l {
+		return 0, io.ErrShortBuffer
+	}
+
+	srcHW, err := normalizeHW(p.SenderHardwareAddr, p.HardwareAddrLength)
+	if err != nil {
+		return 0, err
+	}
+	dstHW, err := normalizeHW(p.TargetHardwareAddr, p.HardwareAddrLength)
+	if err != nil {
+		return 0, err
+	}
+
+	// The buffer may be reused, so clear any stale data.
+	b = b[:l]
+	for i := range b {
+		b[i] = 0
+	}
 
 	// Marshal fixed length data
 
-	binary.BigEndian.PutUint16(b[0:2], p.HardwareType)
+	binary.BigEndian.PutUint16(b[0:2], uint16(p.HardwareType))
 	binary.BigEndian.PutUint16(b[2:4], p.ProtocolType)
 
 	b[4] = p.HardwareAddrLength
@@ -158,32 +405,136 @@ func (p *Packet) MarshalBinary() ([]byte, error) {
 	hal := int(p.HardwareAddrLength)
 	pl := int(p.IPLength)
 
-	copy(b[n:n+hal], p.SenderHardwareAddr)
+	copy(b[n:n+hal], srcHW)
 	n += hal
 
-	sender4 := p.SenderIP.As4()
-	copy(b[n:n+pl], sender4[:])
+	putAddr(b[n:n+pl], p.SenderIP)
 	n += pl
 
-	copy(b[n:n+hal], p.TargetHardwareAddr)
+	copy(b[n:n+hal], dstHW)
 	n += hal
 
-	target4 := p.TargetIP.As4()
-	copy(b[n:n+pl], target4[:])
+	putAddr(b[n:n+pl], p.TargetIP)
 
-	return b, nil
+	return l, nil
+}
+
+// putAddr copies the protocol address ip into b without allocating.  IPv4
+// addresses are copied as 4 bytes into 4 byte fields, and all other addresses
+// as 16 bytes.  If ip is the zero Addr, b is left unmodified.
+func putAddr(b []byte, ip netip.Addr) {
+	switch {
+	case !ip.IsValid():
+	case len(b) == 4 && (ip.Is4() || ip.Is4In6()):
+		a := ip.As4()
+		copy(b, a[:])
+	default:
+		a := ip.As16()
+		copy(b, a[:])
+	}
+}
+
+// length returns the length of p when marshaled, using the address lengths
+// declared in p.
+func (p *Packet) length() int {
+	return 8 + 2*int(p.HardwareAddrLength) + 2*int(p.IPLength)
+}
+
+// ParseHex parses a hexadecimal dump of an ARP packet, such as one copied
+// from a packet capture tool, into a Packet.  Whitespace in s is ignored.
+// ParseHex is intended as a convenience for tests and debugging.
+func ParseHex(s string) (*Packet, error) {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(Packet)
+	if err := p.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// normalizeHW validates hw against the declared hardware address length of
+// a Packet.  If hw is shorter than length, a zero-padded copy is returned, so
+// that, for example, the ethernet broadcast address may be used as the
+// target of a request with longer hardware addresses.  If hw is longer than
+// length, it would be truncated, so ErrInvalidHardwareAddr is returned.
+func normalizeHW(hw net.HardwareAddr, length uint8) (net.HardwareAddr, error) {
+	switch l := int(length); {
+	case len(hw) == l:
+		return hw, nil
+	case len(hw) > l:
+		return nil, ErrInvalidHardwareAddr
+	default:
+		out := make(net.HardwareAddr, l)
+		copy(out, hw)
+		return out, nil
+	}
 }
 
 // UnmarshalBinary unmarshals a raw byte slice into a Packet.
+//
+// If p was previously populated by UnmarshalBinary and the address lengths
+// are unchanged, its hardware address slices are reused rather than
+// reallocated, so decoding repeatedly into the same Packet does not
+// allocate.  Callers which retain hardware addresses from a Packet that is
+// reused must copy them first.
 func (p *Packet) UnmarshalBinary(b []byte) error {
+	prevML, prevIL := p.HardwareAddrLength, p.IPLength
+
+	n, err := p.unmarshalHeader(b)
+	if err != nil {
+		return err
+	}
+
+	bb := p.reuseAddrs(n-8, prevML == p.HardwareAddrLength && prevIL == p.IPLength)
+	if bb == nil {
+		// Allocate single byte slice to store address information, which
+		// is resliced into fields
+		bb = make([]byte, n-8)
+	}
+	copy(bb, b[8:n])
+
+	return p.unmarshalAddrs(bb)
+}
+
+// reuseAddrs returns the n byte address buffer previously allocated for p by
+// UnmarshalBinary, or nil if it cannot be reused.  Only a buffer laid out by
+// unmarshalAddrs is returned, so hardware addresses supplied by a caller,
+// such as ethernet.Broadcast, are never overwritten.
+func (p *Packet) reuseAddrs(n int, sameLengths bool) []byte {
+	ml := int(p.HardwareAddrLength)
+	sha, tha := p.SenderHardwareAddr, p.TargetHardwareAddr
+
+	if !sameLengths || ml == 0 || len(sha) != ml || len(tha) != ml || cap(sha) < n {
+		return nil
+	}
+
+	// The target hardware address must immediately follow the sender
+	// hardware and IP addresses in the same buffer.
+	bb := sha[:n]
+	if &bb[ml+int(p.IPLength)] != &tha[0] {
+		return nil
+	}
+
+	return bb
+}
+
+// unmarshalHeader unmarshals the fixed length portion of a Packet from b,
+// and returns the length of the Packet, including its variable length
+// address data.
+func (p *Packet) unmarshalHeader(b []byte) (int, error) {
 	// Must have enough room to retrieve hardware address and IP lengths
 	if len(b) < 8 {
-		return io.ErrUnexpectedEOF
+		return 0, io.ErrUnexpectedEOF
 	}
 
 	// Retrieve fixed length data
 
-	p.HardwareType = binary.BigEndian.Uint16(b[0:2])
+	p.HardwareType = HardwareType(binary.BigEndian.Uint16(b[0:2]))
 	p.ProtocolType = binary.BigEndian.Uint16(b[2:4])
 
 	p.HardwareAddrLength = b[4]
@@ -191,48 +542,40 @@ func (p *Packet) UnmarshalBinary(b []byte) error {
 
 	p.Operation = Operation(binary.BigEndian.Uint16(b[6:8]))
 
-	// Unmarshal variable length data at correct offset using lengths
-	// defined by ml and il
-	//
+	// Must have enough room to retrieve both hardware address and IP addresses
+	addrl := 8 + int(p.HardwareAddrLength)*2 + int(p.IPLength)*2
+	if len(b) < addrl {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	return addrl, nil
+}
+
+// unmarshalAddrs unmarshals the variable length address data of a Packet
+// from bb, using the lengths retrieved by unmarshalHeader.  The hardware
+// address fields of p alias bb.
+func (p *Packet) unmarshalAddrs(bb []byte) error {
 	// These variables are meant to improve readability of offset calculations
 	// for the code below
-	n := 8
 	ml := int(p.HardwareAddrLength)
 	ml2 := ml * 2
 	il := int(p.IPLength)
 	il2 := il * 2
 
-	// Must have enough room to retrieve both hardware address and IP addresses
-	addrl := n + ml2 + il2
-	if len(b) < addrl {
-		return io.ErrUnexpectedEOF
-	}
-
-	// Allocate single byte slice to store address information, which
-	// is resliced into fields
-	bb := make([]byte, addrl-n)
-
 	// Sender hardware address
-	copy(bb[0:ml], b[n:n+ml])
 	p.SenderHardwareAddr = bb[0:ml]
-	n += ml
 
 	// Sender IP address
-	copy(bb[ml:ml+il], b[n:n+il])
 	senderIP, ok := netip.AddrFromSlice(bb[ml : ml+il])
 	if !ok {
 		return errors.New("Invalid Sender IP address")
 	}
 	p.SenderIP = senderIP
-	n += il
 
 	// Target hardware address
-	copy(bb[ml+il:ml2+il], b[n:n+ml])
 	p.TargetHardwareAddr = bb[ml+il : ml2+il]
-	n += ml
 
 	// Target IP address
-	copy(bb[ml2+il:ml2+il2], b[n:n+il])
 	targetIP, ok := netip.AddrFromSlice(bb[ml2+il : ml2+il2])
 	if !ok {
 		return errors.New("Invalid Target IP address")
@@ -242,6 +585,87 @@ func (p *Packet) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
+// GratuitousFrame builds a gratuitous ARP announcement, which announces that
+// mac owns ip, and returns the bytes of the ethernet frame carrying it, sent
+// from srcMAC to the ethernet broadcast address.  The frame is ready to be
+// written to any raw socket, for callers which manage their own sockets
+// instead of using a Client.
+//
+// The target hardware address of the announcement is the broadcast address
+// matching the length of mac: either the ethernet broadcast address, or the
+// IP over Infiniband broadcast address for 20 byte hardware addresses.
+// Ethernet frames always use 6 byte hardware addresses, so srcMAC must be 6
+// bytes in length, or ErrInvalidHardwareAddr is returned.  For ethernet
+// interfaces, srcMAC and mac are normally the same address.
+func GratuitousFrame(hwType HardwareType, srcMAC, mac net.HardwareAddr, ip netip.Addr) ([]byte, error) {
+	p, err := NewPacket(OperationRequest, mac, ip, broadcastFor(len(mac)), ip)
+	if err != nil {
+		return nil, err
+	}
+	p.HardwareType = hwType
+
+	return p.BroadcastFrameBytes(srcMAC)
+}
+
+// BroadcastFrameBytes returns the bytes of an ethernet frame carrying p,
+// sent from srcMAC to the ethernet broadcast address.  These are the same
+// bytes a Client writes for a broadcast packet, which is useful for tests
+// and callers which send frames using their own sockets.
+//
+// Ethernet frames always use 6 byte hardware addresses, so srcMAC must be 6
+// bytes in length, or ErrInvalidHardwareAddr is returned.  Packets with
+// longer hardware addresses, such as IP over Infiniband packets, carry their
+// own broadcast address in the packet's target hardware address.
+func (p *Packet) BroadcastFrameBytes(srcMAC net.HardwareAddr) ([]byte, error) {
+	if len(srcMAC) != len(ethernet.Broadcast) {
+		return nil, ErrInvalidHardwareAddr
+	}
+
+	return marshalFrame(p, srcMAC, ethernet.Broadcast, nil)
+}
+
+// broadcastFor returns the broadcast hardware address for hardware
+// addresses which are n bytes in length.
+func broadcastFor(n int) net.HardwareAddr {
+	if n == len(infinibandBroadcast) {
+		return infinibandBroadcast
+	}
+	return ethernet.Broadcast
+}
+
+// marshalFrame marshals p into an ethernet frame sent from src and addressed
+// to dst.  If vlan is not nil, the frame carries an 802.1Q VLAN tag.
+func marshalFrame(p *Packet, src, dst net.HardwareAddr, vlan *ethernet.VLAN) ([]byte, error) {
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &ethernet.Frame{
+		Destination: dst,
+		Source:      src,
+		VLAN:        vlan,
+		EtherType:   ethernet.EtherTypeARP,
+		Payload:     pb,
+	}
+
+	return f.MarshalBinary()
+}
+
+// ParseEthernetARP parses an ethernet frame carrying an ARP packet from b,
+// and returns both the frame and the packet, so frame-level fields such as
+// the source and destination hardware addresses and VLAN tags can be
+// inspected alongside the packet.
+//
+// If the frame does not carry an ARP packet, an error is returned.
+func ParseEthernetARP(b []byte) (*ethernet.Frame, *Packet, error) {
+	p, f, err := parsePacket(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, p, nil
+}
+
 func parsePacket(buf []byte) (*Packet, *ethernet.Frame, error) {
 	f := new(ethernet.Frame)
 	if err := f.UnmarshalBinary(buf); err != nil {
@@ -259,3 +683,31 @@ func parsePacket(buf []byte) (*Packet, *ethernet.Frame, error) {
 	}
 	return p, f, nil
 }
+
+// arpPayload returns the ARP payload of the ethernet frame in b, skipping
+// any VLAN tags, without copying or allocating.  If the frame does not carry
+// an ARP packet, errInvalidARPPacket is returned.
+func arpPayload(b []byte) ([]byte, error) {
+	// Verify that both hardware addresses and a single EtherType are present
+	if len(b) < 14 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	n := 12
+	et := ethernet.EtherType(binary.BigEndian.Uint16(b[n : n+2]))
+	for et == ethernet.EtherTypeVLAN || et == ethernet.EtherTypeServiceVLAN {
+		// Skip the VLAN tag to find the next EtherType
+		n += 4
+		if len(b) < n+2 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		et = ethernet.EtherType(binary.BigEndian.Uint16(b[n : n+2]))
+	}
+
+	// Ignore frames which do not have ARP EtherType
+	if et != ethernet.EtherTypeARP {
+		return nil, errInvalidARPPacket
+	}
+
+	return b[n+2:], nil
+}
@@ -2,6 +2,7 @@ package arp
 
 import (
 	"bytes"
+	"encoding/hex"
 	"io"
 	"net"
 	"net/netip"
@@ -66,7 +67,7 @@ func TestNewPacket(t *testing.T) {
 			srcIP: netip.IPv4Unspecified(),
 			dstIP: netip.IPv4Unspecified(),
 			p: &Packet{
-				HardwareType:       1,
+				HardwareType:       HardwareTypeEthernet,
 				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
 				HardwareAddrLength: 20,
 				IPLength:           4,
@@ -85,7 +86,7 @@ func TestNewPacket(t *testing.T) {
 			srcIP: netip.IPv4Unspecified(),
 			dstIP: netip.IPv4Unspecified(),
 			p: &Packet{
-				HardwareType:       1,
+				HardwareType:       HardwareTypeEthernet,
 				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
 				HardwareAddrLength: 6,
 				IPLength:           4,
@@ -116,6 +117,109 @@ func TestNewPacket(t *testing.T) {
 	}
 }
 
+func TestNewPacketProtocol(t *testing.T) {
+	zeroHW := net.HardwareAddr{0, 0, 0, 0, 0, 0}
+	srcIP := netip.MustParseAddr("2001:db8::1")
+	dstIP := netip.MustParseAddr("2001:db8::2")
+
+	tests := []struct {
+		desc     string
+		protocol uint16
+		srcIP    netip.Addr
+		dstIP    netip.Addr
+		p        *Packet
+		err      error
+	}{
+		{
+			desc:     "IPv4 protocol, IPv6 addresses",
+			protocol: uint16(ethernet.EtherTypeIPv4),
+			srcIP:    srcIP,
+			dstIP:    dstIP,
+			err:      ErrInvalidIP,
+		},
+		{
+			desc:     "invalid destination address",
+			protocol: uint16(ethernet.EtherTypeIPv6),
+			srcIP:    srcIP,
+			err:      ErrInvalidIP,
+		},
+		{
+			desc:     "address length mismatch",
+			protocol: uint16(ethernet.EtherTypeIPv6),
+			srcIP:    srcIP,
+			dstIP:    netip.MustParseAddr("192.168.1.1"),
+			err:      ErrInvalidIP,
+		},
+		{
+			desc:     "OK IPv6 protocol",
+			protocol: uint16(ethernet.EtherTypeIPv6),
+			srcIP:    srcIP,
+			dstIP:    dstIP,
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv6),
+				HardwareAddrLength: 6,
+				IPLength:           16,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: zeroHW,
+				SenderIP:           srcIP,
+				TargetHardwareAddr: zeroHW,
+				TargetIP:           dstIP,
+			},
+		},
+		{
+			desc:     "OK experimental protocol, 4 byte addresses",
+			protocol: 0x88b5,
+			srcIP:    netip.MustParseAddr("10.0.0.1"),
+			dstIP:    netip.MustParseAddr("10.0.0.2"),
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       0x88b5,
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: zeroHW,
+				SenderIP:           netip.MustParseAddr("10.0.0.1"),
+				TargetHardwareAddr: zeroHW,
+				TargetIP:           netip.MustParseAddr("10.0.0.2"),
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		p, err := NewPacketProtocol(OperationRequest, tt.protocol, zeroHW, tt.srcIP, zeroHW, tt.dstIP)
+		if err != nil {
+			if want, got := tt.err, err; want != got {
+				t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+					i, tt.desc, want, got)
+			}
+
+			continue
+		}
+
+		if want, got := tt.p, p; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected Packet:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+
+		// Packets must round trip through their binary form.
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatalf("[%02d] test %q, failed to marshal: %v", i, tt.desc, err)
+		}
+
+		p2 := new(Packet)
+		if err := p2.UnmarshalBinary(b); err != nil {
+			t.Fatalf("[%02d] test %q, failed to unmarshal: %v", i, tt.desc, err)
+		}
+
+		if want, got := tt.p, p2; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected round trip Packet:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
 func TestPacketMarshalBinary(t *testing.T) {
 	zeroHW := net.HardwareAddr{0, 0, 0, 0, 0, 0}
 	ip1 := netip.MustParseAddr("192.168.1.10")
@@ -132,7 +236,7 @@ func TestPacketMarshalBinary(t *testing.T) {
 		{
 			desc: "ARP request to ethernet broadcast, 6 byte hardware addresses",
 			p: &Packet{
-				HardwareType:       1,
+				HardwareType:       HardwareTypeEthernet,
 				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
 				HardwareAddrLength: 6,
 				IPLength:           4,
@@ -157,7 +261,7 @@ func TestPacketMarshalBinary(t *testing.T) {
 		{
 			desc: "ARP reply over infiniband, 20 byte hardware addresses",
 			p: &Packet{
-				HardwareType:       32,
+				HardwareType:       HardwareTypeInfiniband,
 				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
 				HardwareAddrLength: 20,
 				IPLength:           4,
@@ -196,6 +300,204 @@ func TestPacketMarshalBinary(t *testing.T) {
 	}
 }
 
+func TestPacketMarshalBinaryLength(t *testing.T) {
+	ip1 := netip.MustParseAddr("192.168.1.10")
+	ip2 := netip.MustParseAddr("192.168.1.1")
+
+	tests := []struct {
+		desc string
+		hw   net.HardwareAddr
+		n    int
+	}{
+		{
+			desc: "ethernet",
+			hw:   net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			n:    28,
+		},
+		{
+			desc: "infiniband",
+			hw:   net.HardwareAddr(bytes.Repeat([]byte{1}, 20)),
+			n:    56,
+		},
+	}
+
+	for i, tt := range tests {
+		p, err := NewPacket(OperationRequest, tt.hw, ip1, tt.hw, ip2)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want, got := 8+2*len(tt.hw)+2*4, len(b); want != got {
+			t.Fatalf("[%02d] test %q, unexpected Packet length: %d != %d",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.n, len(b); want != got {
+			t.Fatalf("[%02d] test %q, Packet was padded: %d != %d",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestPacketMarshalTo(t *testing.T) {
+	p, err := NewPacket(
+		OperationRequest,
+		net.HardwareAddr{0xad, 0xbe, 0xef, 0xde, 0xad, 0xde},
+		netip.MustParseAddr("192.168.1.10"),
+		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		netip.MustParseAddr("192.168.1.1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := p.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.MarshalTo(make([]byte, len(want)-1)); err != io.ErrShortBuffer {
+		t.Fatalf("unexpected error for short buffer: %v != %v", io.ErrShortBuffer, err)
+	}
+
+	// Reuse a larger buffer containing stale data.
+	b := bytes.Repeat([]byte{0xff}, 64)
+	n, err := p.MarshalTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b[:n]; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected Packet bytes:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestPacketMarshalBinaryInvalidHardwareAddr(t *testing.T) {
+	p := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationRequest,
+		SenderHardwareAddr: net.HardwareAddr(bytes.Repeat([]byte{1}, 20)),
+		SenderIP:           netip.MustParseAddr("192.168.1.10"),
+		TargetHardwareAddr: ethernet.Broadcast,
+		TargetIP:           netip.MustParseAddr("192.168.1.1"),
+	}
+
+	if _, err := p.MarshalBinary(); err != ErrInvalidHardwareAddr {
+		t.Fatalf("unexpected error: %v != %v", ErrInvalidHardwareAddr, err)
+	}
+}
+
+func Test_normalizeHW(t *testing.T) {
+	tests := []struct {
+		desc   string
+		hw     net.HardwareAddr
+		length uint8
+		out    net.HardwareAddr
+		err    error
+	}{
+		{
+			desc:   "matching length",
+			hw:     net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			length: 6,
+			out:    net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		},
+		{
+			desc:   "shorter, padded",
+			hw:     ethernet.Broadcast,
+			length: 8,
+			out:    net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x00},
+		},
+		{
+			desc:   "longer",
+			hw:     net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			length: 4,
+			err:    ErrInvalidHardwareAddr,
+		},
+	}
+
+	for i, tt := range tests {
+		out, err := normalizeHW(tt.hw, tt.length)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.out, out; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware address:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestPacketUnmarshalBinaryReuse(t *testing.T) {
+	newPacket := func(sender net.HardwareAddr) *Packet {
+		p, err := NewPacket(
+			OperationRequest,
+			sender,
+			netip.MustParseAddr("192.168.1.10"),
+			ethernet.Broadcast,
+			netip.MustParseAddr("192.168.1.1"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p
+	}
+
+	pb1, err := newPacket(net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb2, err := newPacket(net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Caller supplied hardware addresses must never be overwritten.
+	p := newPacket(net.HardwareAddr{0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc})
+	p.TargetHardwareAddr = net.HardwareAddr{0xdd, 0xdd, 0xdd, 0xdd, 0xdd, 0xdd}
+	target := p.TargetHardwareAddr
+
+	if err := p.UnmarshalBinary(pb1); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := byte(0xdd), target[0]; want != got {
+		t.Fatalf("caller hardware address was overwritten: %#x != %#x", want, got)
+	}
+
+	sender := p.SenderHardwareAddr
+	if err := p.UnmarshalBinary(pb2); err != nil {
+		t.Fatal(err)
+	}
+
+	if &sender[0] != &p.SenderHardwareAddr[0] {
+		t.Fatal("hardware address buffer was not reused")
+	}
+	if want, got := (net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}), p.SenderHardwareAddr; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected sender hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := ethernet.Broadcast, p.TargetHardwareAddr; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected target hardware address:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := p.UnmarshalBinary(pb1); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected zero allocations, got %v", allocs)
+	}
+}
+
 func TestPacketUnmarshalBinary(t *testing.T) {
 	zeroHW := net.HardwareAddr{0, 0, 0, 0, 0, 0}
 	ip1 := netip.MustParseAddr("192.168.1.10")
@@ -251,7 +553,7 @@ func TestPacketUnmarshalBinary(t *testing.T) {
 				192, 168, 1, 1,
 			},
 			p: &Packet{
-				HardwareType:       1,
+				HardwareType:       HardwareTypeEthernet,
 				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
 				HardwareAddrLength: 6,
 				IPLength:           4,
@@ -278,7 +580,7 @@ func TestPacketUnmarshalBinary(t *testing.T) {
 				192, 168, 1, 1,
 			},
 			p: &Packet{
-				HardwareType:       32,
+				HardwareType:       HardwareTypeInfiniband,
 				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
 				HardwareAddrLength: 20,
 				IPLength:           4,
@@ -360,7 +662,7 @@ func Test_parsePacket(t *testing.T) {
 				192, 168, 1, 1,
 			}, make([]byte, 40)...),
 			p: &Packet{
-				HardwareType:       1,
+				HardwareType:       HardwareTypeEthernet,
 				ProtocolType:       2054,
 				HardwareAddrLength: 6,
 				IPLength:           4,
@@ -391,28 +693,739 @@ func Test_parsePacket(t *testing.T) {
 	}
 }
 
-// Benchmarks for Packet.MarshalBinary
+func TestParseHex(t *testing.T) {
+	const s = `
+		0001 0800 0604 0002
+		aabb ccdd eeff c0a8 010a
+		dead beef dead c0a8 0101
+	`
 
-func BenchmarkPacketMarshalBinary(b *testing.B) {
-	p, err := NewPacket(
-		OperationRequest,
-		net.HardwareAddr{0xad, 0xbe, 0xef, 0xde, 0xad, 0xde},
-		netip.MustParseAddr("192.168.1.10"),
-		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
-		netip.MustParseAddr("192.168.1.1"),
-	)
+	p, err := ParseHex(s)
 	if err != nil {
-		b.Fatal(err)
+		t.Fatal(err)
 	}
 
-	benchmarkPacketMarshalBinary(b, p)
+	want := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationReply,
+		SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		SenderIP:           netip.MustParseAddr("192.168.1.10"),
+		TargetHardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		TargetIP:           netip.MustParseAddr("192.168.1.1"),
+	}
+	if got := p; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if _, err := ParseHex("0001 080"); err != hex.ErrLength {
+		t.Fatalf("unexpected error for odd length input: %v != %v", hex.ErrLength, err)
+	}
 }
 
-func benchmarkPacketMarshalBinary(b *testing.B, p *Packet) {
-	b.ResetTimer()
-	b.ReportAllocs()
-	for i := 0; i < b.N; i++ {
-		if _, err := p.MarshalBinary(); err != nil {
+func TestParseEthernetARP(t *testing.T) {
+	b := append([]byte{
+		// Ethernet frame
+		0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		0x08, 0x06,
+		// ARP Packet
+		0, 1,
+		0x08, 0x06,
+		6,
+		4,
+		0, 2,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		192, 168, 1, 10,
+		0xde, 0xad, 0xbe, 0xef, 0xde, 0xad,
+		192, 168, 1, 1,
+	}, make([]byte, 40)...)
+
+	f, p, err := ParseEthernetARP(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := (net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}), f.Destination; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected frame destination:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := (net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}), f.Source; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected frame source:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := ethernet.EtherTypeARP, f.EtherType; want != got {
+		t.Fatalf("unexpected frame EtherType: %v != %v", want, got)
+	}
+
+	want := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       2054,
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationReply,
+		SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		SenderIP:           netip.MustParseAddr("192.168.1.10"),
+		TargetHardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		TargetIP:           netip.MustParseAddr("192.168.1.1"),
+	}
+	if got := p; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	if _, _, err := ParseEthernetARP(make([]byte, 56)); err != errInvalidARPPacket {
+		t.Fatalf("unexpected error for non-ARP frame: %v", err)
+	}
+}
+
+func TestPacketBroadcastFrameBytes(t *testing.T) {
+	src := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	ip1 := netip.MustParseAddr("192.168.1.10")
+	ip2 := netip.MustParseAddr("192.168.1.1")
+
+	tests := []struct {
+		desc string
+		p    *Packet
+	}{
+		{
+			desc: "ethernet",
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: src,
+				SenderIP:           ip1,
+				TargetHardwareAddr: ethernet.Broadcast,
+				TargetIP:           ip2,
+			},
+		},
+		{
+			desc: "IPoIB",
+			p: &Packet{
+				HardwareType:       HardwareTypeInfiniband,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 20,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: net.HardwareAddr(bytes.Repeat([]byte{1}, 20)),
+				SenderIP:           ip1,
+				TargetHardwareAddr: infinibandBroadcast,
+				TargetIP:           ip2,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		b, err := tt.p.BroadcastFrameBytes(src)
+		if err != nil {
+			t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+		}
+
+		f, p, err := ParseEthernetARP(b)
+		if err != nil {
+			t.Fatalf("[%02d] test %q, failed to parse frame: %v", i, tt.desc, err)
+		}
+
+		if want, got := ethernet.Broadcast, f.Destination; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected frame destination: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := src, f.Source; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected frame source: %v != %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := tt.p, p; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected Packet:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := int(tt.p.HardwareAddrLength), len(p.TargetHardwareAddr); want != got {
+			t.Fatalf("[%02d] test %q, unexpected broadcast address length: %d != %d",
+				i, tt.desc, want, got)
+		}
+	}
+
+	p := tests[0].p
+	if _, err := p.BroadcastFrameBytes(p.TargetHardwareAddr[:4]); err != ErrInvalidHardwareAddr {
+		t.Fatalf("unexpected error for short source address: %v", err)
+	}
+}
+
+func TestParseEthernetARPVLAN(t *testing.T) {
+	b := append([]byte{
+		// Ethernet frame with 802.1Q VLAN tag
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		0x81, 0x00,
+		0x00, 0x0a,
+		0x08, 0x06,
+		// ARP Packet
+		0, 1,
+		0x08, 0x00,
+		6,
+		4,
+		0, 1,
+		0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff,
+		192, 168, 1, 10,
+		0, 0, 0, 0, 0, 0,
+		192, 168, 1, 1,
+	}, make([]byte, 14)...)
+
+	f, p, err := ParseEthernetARP(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.VLAN == nil {
+		t.Fatal("frame has no VLAN tag")
+	}
+	if want, got := uint16(10), f.VLAN.ID; want != got {
+		t.Fatalf("unexpected VLAN ID: %d != %d", want, got)
+	}
+	if want, got := ethernet.EtherTypeARP, f.EtherType; want != got {
+		t.Fatalf("unexpected frame EtherType: %v != %v", want, got)
+	}
+
+	want := &Packet{
+		HardwareType:       HardwareTypeEthernet,
+		ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+		HardwareAddrLength: 6,
+		IPLength:           4,
+		Operation:          OperationRequest,
+		SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		SenderIP:           netip.MustParseAddr("192.168.1.10"),
+		TargetHardwareAddr: net.HardwareAddr{0, 0, 0, 0, 0, 0},
+		TargetIP:           netip.MustParseAddr("192.168.1.1"),
+	}
+	if got := p; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Packet:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestGratuitousFrame(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	src := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	iboip := net.HardwareAddr(bytes.Repeat([]byte{1}, 20))
+
+	tests := []struct {
+		desc string
+		ht   HardwareType
+		src  net.HardwareAddr
+		mac  net.HardwareAddr
+		p    *Packet
+		err  error
+	}{
+		{
+			desc: "ethernet",
+			ht:   HardwareTypeEthernet,
+			src:  src,
+			mac:  src,
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				SenderIP:           ip,
+				TargetHardwareAddr: ethernet.Broadcast,
+				TargetIP:           ip,
+			},
+		},
+		{
+			desc: "IPoIB",
+			ht:   HardwareTypeInfiniband,
+			src:  src,
+			mac:  iboip,
+			p: &Packet{
+				HardwareType:       HardwareTypeInfiniband,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 20,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: iboip,
+				SenderIP:           ip,
+				TargetHardwareAddr: infinibandBroadcast,
+				TargetIP:           ip,
+			},
+		},
+		{
+			desc: "IPoIB frame source",
+			ht:   HardwareTypeInfiniband,
+			src:  iboip,
+			mac:  iboip,
+			err:  ErrInvalidHardwareAddr,
+		},
+	}
+
+	for i, tt := range tests {
+		b, err := GratuitousFrame(tt.ht, tt.src, tt.mac, ip)
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if err != nil {
+			continue
+		}
+
+		f, p, err := ParseEthernetARP(b)
+		if err != nil {
+			t.Fatalf("[%02d] test %q, failed to parse frame: %v", i, tt.desc, err)
+		}
+
+		if want, got := ethernet.Broadcast, f.Destination; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected frame destination:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.src, f.Source; !bytes.Equal(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected frame source:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.p, p; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected Packet:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestPacketIsRequestIsReply(t *testing.T) {
+	tests := []struct {
+		op      Operation
+		request bool
+		reply   bool
+	}{
+		{op: OperationRequest, request: true},
+		{op: OperationReply, reply: true},
+		{op: 0},
+		{op: 3},
+	}
+
+	for i, tt := range tests {
+		p := &Packet{Operation: tt.op}
+
+		if want, got := tt.request, p.IsRequest(); want != got {
+			t.Fatalf("[%02d] unexpected IsRequest for %v: %v != %v", i, tt.op, want, got)
+		}
+		if want, got := tt.reply, p.IsReply(); want != got {
+			t.Fatalf("[%02d] unexpected IsReply for %v: %v != %v", i, tt.op, want, got)
+		}
+	}
+}
+
+func TestPacketSane(t *testing.T) {
+	zeroHW := net.HardwareAddr{0, 0, 0, 0, 0, 0}
+	hw1 := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	hw2 := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad}
+	ip1 := netip.MustParseAddr("192.168.1.10")
+	ip2 := netip.MustParseAddr("192.168.1.1")
+
+	tests := []struct {
+		desc string
+		p    *Packet
+		err  error
+	}{
+		{
+			desc: "probe request",
+			p: &Packet{
+				Operation:          OperationRequest,
+				SenderHardwareAddr: hw1,
+				SenderIP:           netip.IPv4Unspecified(),
+				TargetHardwareAddr: zeroHW,
+				TargetIP:           ip2,
+			},
+		},
+		{
+			desc: "reply with zero sender hardware address",
+			p: &Packet{
+				Operation:          OperationReply,
+				SenderHardwareAddr: zeroHW,
+				SenderIP:           ip1,
+				TargetHardwareAddr: hw2,
+				TargetIP:           ip2,
+			},
+			err: errZeroSenderHardwareAddr,
+		},
+		{
+			desc: "reply with zero target hardware address",
+			p: &Packet{
+				Operation:          OperationReply,
+				SenderHardwareAddr: hw1,
+				SenderIP:           ip1,
+				TargetHardwareAddr: zeroHW,
+				TargetIP:           ip2,
+			},
+			err: errZeroTargetHardwareAddr,
+		},
+		{
+			desc: "reply with unspecified sender IP",
+			p: &Packet{
+				Operation:          OperationReply,
+				SenderHardwareAddr: hw1,
+				SenderIP:           netip.IPv4Unspecified(),
+				TargetHardwareAddr: hw2,
+				TargetIP:           ip2,
+			},
+			err: errUnspecifiedSenderIP,
+		},
+		{
+			desc: "OK reply",
+			p: &Packet{
+				Operation:          OperationReply,
+				SenderHardwareAddr: hw1,
+				SenderIP:           ip1,
+				TargetHardwareAddr: hw2,
+				TargetIP:           ip2,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.err, tt.p.Sane(); want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestOperationString(t *testing.T) {
+	tests := []struct {
+		op Operation
+		s  string
+	}{
+		{op: OperationRequest, s: "OperationRequest"},
+		{op: OperationReply, s: "OperationReply"},
+		{op: OperationRARPRequest, s: "OperationRARPRequest"},
+		{op: OperationRARPReply, s: "OperationRARPReply"},
+		{op: OperationInARPRequest, s: "OperationInARPRequest"},
+		{op: OperationInARPReply, s: "OperationInARPReply"},
+		{op: 0, s: "Operation(0)"},
+		{op: 5, s: "Operation(5)"},
+		{op: 10, s: "Operation(10)"},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.s, tt.op.String(); want != got {
+			t.Fatalf("[%02d] unexpected operation string: %q != %q", i, want, got)
+		}
+	}
+}
+
+func Test_operationName(t *testing.T) {
+	tests := []struct {
+		op Operation
+		s  string
+	}{
+		{op: OperationRequest, s: "Request"},
+		{op: OperationReply, s: "Reply"},
+		{op: OperationRARPRequest, s: "RARPRequest"},
+		{op: 0, s: "Operation(0)"},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.s, operationName(tt.op); want != got {
+			t.Fatalf("[%02d] unexpected operation name: %q != %q", i, want, got)
+		}
+	}
+}
+
+func TestPacketEqual(t *testing.T) {
+	newPacket := func() *Packet {
+		p, err := NewPacket(
+			OperationReply,
+			net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+			netip.MustParseAddr("192.168.1.10"),
+			net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			netip.MustParseAddr("192.168.1.1"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p
+	}
+
+	tests := []struct {
+		desc  string
+		a, b  *Packet
+		equal bool
+	}{
+		{
+			desc:  "both nil",
+			equal: true,
+		},
+		{
+			desc: "one nil",
+			a:    newPacket(),
+		},
+		{
+			desc:  "equal",
+			a:     newPacket(),
+			b:     newPacket(),
+			equal: true,
+		},
+		{
+			desc: "IPv4-mapped IPv6 address",
+			a:    newPacket(),
+			b: func() *Packet {
+				p := newPacket()
+				p.SenderIP = netip.MustParseAddr("::ffff:192.168.1.10")
+				return p
+			}(),
+			equal: true,
+		},
+		{
+			desc: "different operation",
+			a:    newPacket(),
+			b: func() *Packet {
+				p := newPacket()
+				p.Operation = OperationRequest
+				return p
+			}(),
+		},
+		{
+			desc: "different hardware address",
+			a:    newPacket(),
+			b: func() *Packet {
+				p := newPacket()
+				p.TargetHardwareAddr = ethernet.Broadcast
+				return p
+			}(),
+		},
+		{
+			desc: "different IP address",
+			a:    newPacket(),
+			b: func() *Packet {
+				p := newPacket()
+				p.TargetIP = netip.MustParseAddr("192.168.1.2")
+				return p
+			}(),
+		},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.equal, tt.a.Equal(tt.b); want != got {
+			t.Fatalf("[%02d] test %q, unexpected equality: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.equal, tt.b.Equal(tt.a); want != got {
+			t.Fatalf("[%02d] test %q, unexpected reversed equality: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestPacketClone(t *testing.T) {
+	p, err := NewPacket(
+		OperationReply,
+		net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		netip.MustParseAddr("192.168.1.10"),
+		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		netip.MustParseAddr("192.168.1.1"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := p.Clone()
+	if !p.Equal(c) {
+		t.Fatalf("clone is not equal:\n- want: %v\n-  got: %v", p, c)
+	}
+
+	p.SenderHardwareAddr[0] = 0xff
+	p.TargetHardwareAddr[0] = 0xff
+	if c.SenderHardwareAddr[0] != 0xaa || c.TargetHardwareAddr[0] != 0xde {
+		t.Fatalf("clone aliases original hardware addresses: %v", c)
+	}
+
+	if (*Packet)(nil).Clone() != nil {
+		t.Fatal("clone of nil Packet is not nil")
+	}
+}
+
+func TestPacketString(t *testing.T) {
+	ip1 := netip.MustParseAddr("192.168.1.10")
+	ip2 := netip.MustParseAddr("192.168.1.1")
+
+	iboip1 := net.HardwareAddr(bytes.Repeat([]byte{0}, 20))
+	iboip2 := net.HardwareAddr(bytes.Repeat([]byte{1}, 20))
+
+	tests := []struct {
+		desc string
+		p    *Packet
+		s    string
+	}{
+		{
+			desc: "IPv4 over Ethernet",
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				SenderIP:           ip1,
+				TargetHardwareAddr: ethernet.Broadcast,
+				TargetIP:           ip2,
+			},
+			s: "ARP request 192.168.1.10 (aa:bb:cc:dd:ee:ff) -> 192.168.1.1 (ff:ff:ff:ff:ff:ff) [Ethernet, IPv4]",
+		},
+		{
+			desc: "IPv4 over Infiniband",
+			p: &Packet{
+				HardwareType:       HardwareTypeInfiniband,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 20,
+				IPLength:           4,
+				Operation:          OperationReply,
+				SenderHardwareAddr: iboip1,
+				SenderIP:           ip1,
+				TargetHardwareAddr: iboip2,
+				TargetIP:           ip2,
+			},
+			s: "ARP reply 192.168.1.10 (" + iboip1.String() + ") -> 192.168.1.1 (" + iboip2.String() + ") [Infiniband, IPv4]",
+		},
+		{
+			desc: "unknown types",
+			p: &Packet{
+				HardwareType:       99,
+				ProtocolType:       0x1234,
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          99,
+				SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				SenderIP:           ip1,
+				TargetHardwareAddr: ethernet.Broadcast,
+				TargetIP:           ip2,
+			},
+			s: "ARP Operation(99) 192.168.1.10 (aa:bb:cc:dd:ee:ff) -> 192.168.1.1 (ff:ff:ff:ff:ff:ff) [HardwareType(99), 0x1234]",
+		},
+		{
+			desc: "gratuitous reply",
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationReply,
+				SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				SenderIP:           ip1,
+				TargetHardwareAddr: ethernet.Broadcast,
+				TargetIP:           ip1,
+			},
+			s: "ARP reply (gratuitous) 192.168.1.10 (aa:bb:cc:dd:ee:ff) -> 192.168.1.10 (ff:ff:ff:ff:ff:ff) [Ethernet, IPv4]",
+		},
+		{
+			desc: "RARP request",
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationRARPRequest,
+				SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				SenderIP:           ip2,
+				TargetHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				TargetIP:           ip1,
+			},
+			s: "ARP RARP request 192.168.1.1 (aa:bb:cc:dd:ee:ff) -> 192.168.1.10 (aa:bb:cc:dd:ee:ff) [Ethernet, IPv4]",
+		},
+		{
+			desc: "probe",
+			p: &Packet{
+				HardwareType:       HardwareTypeEthernet,
+				ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+				HardwareAddrLength: 6,
+				IPLength:           4,
+				Operation:          OperationRequest,
+				SenderHardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+				SenderIP:           netip.IPv4Unspecified(),
+				TargetHardwareAddr: net.HardwareAddr{0, 0, 0, 0, 0, 0},
+				TargetIP:           ip1,
+			},
+			s: "ARP request (probe) 0.0.0.0 (aa:bb:cc:dd:ee:ff) -> 192.168.1.10 (00:00:00:00:00:00) [Ethernet, IPv4]",
+		},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.s, tt.p.String(); want != got {
+			t.Fatalf("[%02d] test %q, unexpected Packet string:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestHardwareTypeString(t *testing.T) {
+	tests := []struct {
+		ht HardwareType
+		s  string
+	}{
+		{ht: HardwareTypeEthernet, s: "Ethernet"},
+		{ht: HardwareTypeARCNET, s: "ARCNET"},
+		{ht: HardwareTypeATM, s: "ATM"},
+		{ht: HardwareTypeFibreChannel, s: "FibreChannel"},
+		{ht: HardwareTypeInfiniband, s: "Infiniband"},
+		{ht: 0, s: "HardwareType(0)"},
+		{ht: 19, s: "HardwareType(19)"},
+		{ht: 65535, s: "HardwareType(65535)"},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.s, tt.ht.String(); want != got {
+			t.Fatalf("[%02d] unexpected HardwareType string: %q != %q",
+				i, want, got)
+		}
+	}
+}
+
+// Benchmarks for Packet.MarshalBinary
+
+func BenchmarkPacketMarshalBinary(b *testing.B) {
+	p, err := NewPacket(
+		OperationRequest,
+		net.HardwareAddr{0xad, 0xbe, 0xef, 0xde, 0xad, 0xde},
+		netip.MustParseAddr("192.168.1.10"),
+		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		netip.MustParseAddr("192.168.1.1"),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	benchmarkPacketMarshalBinary(b, p)
+}
+
+func benchmarkPacketMarshalBinary(b *testing.B, p *Packet) {
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.MarshalBinary(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// Benchmarks for Packet.MarshalTo
+
+func BenchmarkPacketMarshalTo(b *testing.B) {
+	p, err := NewPacket(
+		OperationRequest,
+		net.HardwareAddr{0xad, 0xbe, 0xef, 0xde, 0xad, 0xde},
+		netip.MustParseAddr("192.168.1.10"),
+		net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+		netip.MustParseAddr("192.168.1.1"),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	buf := make([]byte, 28)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.MarshalTo(buf); err != nil {
 			b.Fatal(err)
 		}
 	}
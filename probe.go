@@ -0,0 +1,170 @@
+package arp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/mdlayher/ethernet"
+)
+
+// ErrAddressInUse is returned when another machine is already using, or is
+// probing for, an IPv4 address.
+var ErrAddressInUse = errors.New("address already in use")
+
+// Probe sends an ARP probe, as described in RFC 5227, to determine whether
+// an IPv4 address is already in use by another machine.  Probe waits for
+// conflicting ARP packets until ctx is canceled or its deadline is exceeded,
+// so ctx should normally carry a deadline which bounds the probe window.
+//
+// Probe collects the hardware address of every machine which conflicts
+// within the probe window, so callers can detect multiple machines using
+// the same address.  If any conflicts are detected, their hardware
+// addresses are returned along with ErrAddressInUse.  If the deadline of ctx
+// is exceeded without a conflict, Probe returns nil, nil.
+//
+// Probe overrides any read deadline set on the Client, and clears it before
+// returning.
+func (c *Client) Probe(ctx context.Context, ip netip.Addr) ([]net.HardwareAddr, error) {
+	// An ARP probe uses an unspecified sender IPv4 address so it does not
+	// pollute the ARP caches of other machines, and a zero target hardware
+	// address.
+	p, err := c.newPacket(
+		OperationRequest,
+		c.ifi.HardwareAddr, netip.IPv4Unspecified(),
+		make(net.HardwareAddr, len(c.ifi.HardwareAddr)), ip,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.WriteTo(p, ethernet.Broadcast); err != nil {
+		return nil, err
+	}
+
+	var macs []net.HardwareAddr
+	for {
+		arp, _, err := c.readContext(ctx)
+		if err != nil {
+			if err != context.DeadlineExceeded {
+				return macs, err
+			}
+			if len(macs) > 0 {
+				return macs, ErrAddressInUse
+			}
+			return nil, nil
+		}
+
+		if c.conflicts(arp, ip) && !containsHardwareAddr(macs, arp.SenderHardwareAddr) {
+			macs = append(macs, arp.SenderHardwareAddr)
+		}
+	}
+}
+
+// A Responder is a machine which replied to an ARP request sent by
+// DetectDuplicates.
+type Responder struct {
+	// HardwareAddr is the hardware address of the machine.
+	HardwareAddr net.HardwareAddr
+
+	// Time is the time at which the machine's first reply was read.
+	Time time.Time
+}
+
+// DetectDuplicates performs an ARP request and collects every machine which
+// replies on behalf of ip within the duration window, in the order their
+// replies arrived.  More than one Responder indicates that several machines
+// claim the same IPv4 address, and the arrival times show which machine
+// answered first.
+//
+// If ctx is canceled before window elapses, the Responders seen so far are
+// returned along with ctx.Err().  DetectDuplicates overrides any read
+// deadline set on the Client, and clears it before returning.
+func (c *Client) DetectDuplicates(ctx context.Context, ip netip.Addr, window time.Duration) ([]Responder, error) {
+	if err := c.Request(ip); err != nil {
+		return nil, err
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, window)
+	defer cancel()
+
+	var (
+		rs   []Responder
+		macs []net.HardwareAddr
+	)
+
+	for {
+		arp, _, err := c.readContext(wctx)
+		if err != nil {
+			if err := ctx.Err(); err != nil {
+				return rs, err
+			}
+			if err == context.DeadlineExceeded {
+				return rs, nil
+			}
+			return rs, err
+		}
+
+		if !isReplyFor(arp, ip) || containsHardwareAddr(macs, arp.SenderHardwareAddr) {
+			continue
+		}
+
+		macs = append(macs, arp.SenderHardwareAddr)
+		rs = append(rs, Responder{
+			HardwareAddr: arp.SenderHardwareAddr,
+			Time:         time.Now(),
+		})
+	}
+}
+
+// conflicts reports whether p indicates that another machine is using, or
+// probing for, ip, as described in RFC 5227, section 2.1.1.
+func (c *Client) conflicts(p *Packet, ip netip.Addr) bool {
+	// Ignore our own packets, which may be looped back.
+	if bytes.Equal(p.SenderHardwareAddr, c.ifi.HardwareAddr) {
+		return false
+	}
+
+	// Another machine is using ip.
+	if p.SenderIP == ip {
+		return true
+	}
+
+	// Another machine is probing for ip.
+	return p.Operation == OperationRequest && p.SenderIP.IsUnspecified() && p.TargetIP == ip
+}
+
+// Announce broadcasts a gratuitous ARP reply, which announces that the
+// Client's hardware address owns ip, so other machines update their ARP
+// caches.  The sender and target IPv4 addresses of the reply are both ip,
+// and the target hardware address is the ethernet broadcast address.
+// Announce should be used when a machine comes up or changes its IPv4
+// address.
+//
+// Linux and Windows update their caches from gratuitous replies or
+// requests, but some hosts, including older BSD-derived stacks, only honor
+// gratuitous requests; use AnnounceRequest for those hosts.
+func (c *Client) Announce(ip netip.Addr) error {
+	return c.announce(OperationReply, ip)
+}
+
+// AnnounceRequest broadcasts a gratuitous ARP request, which announces that
+// the Client's hardware address owns ip, in the same way as Announce.  A
+// gratuitous request is also the form of announcement recommended by
+// RFC 5227, and is understood by BSD-derived stacks which ignore gratuitous
+// replies.
+func (c *Client) AnnounceRequest(ip netip.Addr) error {
+	return c.announce(OperationRequest, ip)
+}
+
+// announce broadcasts a gratuitous ARP packet with the specified operation,
+// which announces that the Client's hardware address owns ip.
+func (c *Client) announce(op Operation, ip netip.Addr) error {
+	p, err := c.newPacket(op, c.ifi.HardwareAddr, ip, ethernet.Broadcast, ip)
+	if err != nil {
+		return err
+	}
+	return c.WriteTo(p, ethernet.Broadcast)
+}
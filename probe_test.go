@@ -0,0 +1,195 @@
+package arp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/ethernet"
+)
+
+func TestClientProbe(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac := net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	mac2 := net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01}
+
+	tests := []struct {
+		desc   string
+		frames []timedFrame
+		macs   []net.HardwareAddr
+		err    error
+	}{
+		{
+			desc: "no conflict",
+		},
+		{
+			desc: "unrelated reply",
+			frames: []timedFrame{{
+				b: replyFrame(t, mac, netip.MustParseAddr("192.168.1.20")),
+			}},
+		},
+		{
+			desc: "address in use",
+			frames: []timedFrame{{
+				b: replyFrame(t, mac, ip),
+			}},
+			macs: []net.HardwareAddr{mac},
+			err:  ErrAddressInUse,
+		},
+		{
+			desc: "simultaneous probe",
+			frames: []timedFrame{{
+				b: probeFrame(t, mac, ip),
+			}},
+			macs: []net.HardwareAddr{mac},
+			err:  ErrAddressInUse,
+		},
+		{
+			desc: "multiple conflicts",
+			frames: []timedFrame{
+				{b: replyFrame(t, mac, ip)},
+				{b: replyFrame(t, mac2, ip)},
+				{b: replyFrame(t, mac, ip)},
+			},
+			macs: []net.HardwareAddr{mac, mac2},
+			err:  ErrAddressInUse,
+		},
+	}
+
+	for i, tt := range tests {
+		p := newReplyPacketConn(func(_ []byte) []timedFrame {
+			return tt.frames
+		})
+		c := testClient(t, p)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		macs, err := c.Probe(ctx, ip)
+		cancel()
+
+		if want, got := tt.err, err; want != got {
+			t.Fatalf("[%02d] test %q, unexpected error: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if want, got := tt.macs, macs; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hardware addresses:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+
+		// Verify the probe itself was well-formed.
+		probe, _, err := parsePacket(p.writes[0])
+		if err != nil {
+			t.Fatalf("[%02d] test %q, failed to parse probe: %v", i, tt.desc, err)
+		}
+		if !probe.SenderIP.IsUnspecified() || probe.TargetIP != ip {
+			t.Fatalf("[%02d] test %q, unexpected probe: %v", i, tt.desc, probe)
+		}
+	}
+}
+
+func TestClientDetectDuplicates(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	mac1 := net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+	mac2 := net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}
+
+	c := testClient(t, newReplyPacketConn(func(_ []byte) []timedFrame {
+		return []timedFrame{
+			{b: replyFrame(t, mac2, ip), after: 20 * time.Millisecond},
+			{b: replyFrame(t, mac1, ip)},
+			{b: replyFrame(t, mac1, ip), after: 30 * time.Millisecond},
+		}
+	}))
+
+	rs, err := c.DetectDuplicates(context.Background(), ip, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(rs); want != got {
+		t.Fatalf("unexpected number of responders: %d != %d", want, got)
+	}
+
+	// The responder with no delay answered first.
+	if want, got := mac1, rs[0].HardwareAddr; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected first responder:\n- want: %v\n-  got: %v", want, got)
+	}
+	if want, got := mac2, rs[1].HardwareAddr; !bytes.Equal(want, got) {
+		t.Fatalf("unexpected second responder:\n- want: %v\n-  got: %v", want, got)
+	}
+	if !rs[0].Time.Before(rs[1].Time) {
+		t.Fatalf("responders out of order: %v, %v", rs[0].Time, rs[1].Time)
+	}
+}
+
+func TestClientAnnounce(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+
+	tests := []struct {
+		desc     string
+		announce func(c *Client) error
+		op       Operation
+	}{
+		{
+			desc:     "reply",
+			announce: func(c *Client) error { return c.Announce(ip) },
+			op:       OperationReply,
+		},
+		{
+			desc:     "request",
+			announce: func(c *Client) error { return c.AnnounceRequest(ip) },
+			op:       OperationRequest,
+		},
+	}
+
+	for i, tt := range tests {
+		p := newReplyPacketConn(nil)
+		c := testClient(t, p)
+
+		if err := tt.announce(c); err != nil {
+			t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+		}
+
+		eth, arp, err := ParseEthernetARP(p.writes[0])
+		if err != nil {
+			t.Fatalf("[%02d] test %q, failed to parse announcement: %v", i, tt.desc, err)
+		}
+
+		want := &Packet{
+			HardwareType:       HardwareTypeEthernet,
+			ProtocolType:       uint16(ethernet.EtherTypeIPv4),
+			HardwareAddrLength: 6,
+			IPLength:           4,
+			Operation:          tt.op,
+			SenderHardwareAddr: c.HardwareAddr(),
+			SenderIP:           ip,
+			TargetHardwareAddr: ethernet.Broadcast,
+			TargetIP:           ip,
+		}
+		if got := arp; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected announcement:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+
+		if want, got := ethernet.Broadcast.String(), eth.Destination.String(); want != got {
+			t.Fatalf("[%02d] test %q, unexpected frame destination: %v != %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+// probeFrame builds an ethernet frame carrying an ARP probe for ip from the
+// specified hardware address.
+func probeFrame(t *testing.T, mac net.HardwareAddr, ip netip.Addr) []byte {
+	t.Helper()
+
+	p, err := NewPacket(OperationRequest, mac, netip.IPv4Unspecified(),
+		net.HardwareAddr{0, 0, 0, 0, 0, 0}, ip)
+	if err != nil {
+		t.Fatalf("failed to create packet: %v", err)
+	}
+
+	return packetFrame(t, p, ethernet.Broadcast)
+}
@@ -0,0 +1,157 @@
+package arp
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// ScanOptions configures a subnet scan performed by Client.Scan.
+type ScanOptions struct {
+	// Rate is the maximum number of ARP requests sent per second.  If zero
+	// or negative, requests are sent as quickly as possible.
+	Rate int
+
+	// Timeout is the amount of time to wait for replies after the last
+	// request has been sent.  If zero or negative, the Client's
+	// DefaultTimeout is used, or 5 seconds if that is also unset.
+	Timeout time.Duration
+}
+
+// Scan sends an ARP request to every host address in prefix and returns the
+// hardware addresses of the machines which reply.  Requests are sent at the
+// rate configured by opts, while replies are read concurrently, and Scan
+// continues collecting replies until opts.Timeout has elapsed after the last
+// request was sent.  Hosts which do not reply are absent from the result.
+//
+// For prefixes shorter than /31, the network and broadcast addresses are
+// not scanned.  If prefix is not an IPv4 prefix, ErrInvalidIP is returned.
+//
+// Scan must not be used concurrently with Read.  Scan overrides any read
+// deadline set on the Client, and clears it before returning.
+func (c *Client) Scan(prefix netip.Prefix, opts ScanOptions) (map[netip.Addr]net.HardwareAddr, error) {
+	if !prefix.IsValid() || !prefix.Addr().Is4() {
+		return nil, ErrInvalidIP
+	}
+	prefix = prefix.Masked()
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = c.defaultTimeout()
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Read replies in the background while requests are sent, so replies
+	// to early requests are not dropped while later ones are in flight.
+	var (
+		hosts   = make(map[netip.Addr]net.HardwareAddr)
+		readErr error
+		done    = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		for {
+			arp, _, err := c.monitorContext(ctx)
+			if err != nil {
+				readErr = err
+				return
+			}
+
+			if !isReply(arp) || !prefix.Contains(arp.SenderIP) {
+				continue
+			}
+			if _, ok := hosts[arp.SenderIP]; !ok {
+				hosts[arp.SenderIP] = arp.SenderHardwareAddr
+			}
+		}
+	}()
+
+	if err := c.scanRequests(prefix, opts.Rate, done); err != nil {
+		cancel()
+		<-done
+		return nil, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		cancel()
+		<-done
+	case <-done:
+	}
+
+	// Cancelation only stops the reader, but any other error is fatal.
+	if readErr != nil && readErr != context.Canceled {
+		return nil, readErr
+	}
+
+	return hosts, nil
+}
+
+// scanRequests sends an ARP request to every host address in prefix, at no
+// more than rate requests per second.  Sending stops early if done is closed
+// because the reader has failed.
+func (c *Client) scanRequests(prefix netip.Prefix, rate int, done <-chan struct{}) error {
+	var tick <-chan time.Time
+	if rate > 0 && time.Second/time.Duration(rate) > 0 {
+		t := time.NewTicker(time.Second / time.Duration(rate))
+		defer t.Stop()
+		tick = t.C
+	}
+
+	first := true
+	for ip := prefix.Addr(); prefix.Contains(ip); ip = ip.Next() {
+		if !isScanHost(prefix, ip) {
+			continue
+		}
+
+		// Pace every request after the first, and stop if the reader
+		// has failed.
+		if !first && tick != nil {
+			select {
+			case <-done:
+				return nil
+			case <-tick:
+			}
+		} else {
+			select {
+			case <-done:
+				return nil
+			default:
+			}
+		}
+		first = false
+
+		if err := c.Request(ip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isScanHost reports whether ip, which must be contained in prefix, is a
+// host address which should be scanned.
+func isScanHost(prefix netip.Prefix, ip netip.Addr) bool {
+	// Point-to-point and single host prefixes have no network or broadcast
+	// address.
+	if prefix.Bits() >= 31 {
+		return true
+	}
+
+	return ip != prefix.Addr() && prefix.Contains(ip.Next())
+}
+
+// isReply reports whether p is an ARP reply from a specified sender IPv4
+// address.
+func isReply(p *Packet) bool {
+	return isReplyFor(p, p.SenderIP)
+}
@@ -0,0 +1,109 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClientScan(t *testing.T) {
+	mac2 := net.HardwareAddr{0x22, 0x22, 0x22, 0x22, 0x22, 0x22}
+	mac5 := net.HardwareAddr{0x55, 0x55, 0x55, 0x55, 0x55, 0x55}
+	outside := net.HardwareAddr{0x99, 0x99, 0x99, 0x99, 0x99, 0x99}
+
+	var targets []netip.Addr
+	p := newReplyPacketConn(func(b []byte) []timedFrame {
+		req, _, err := parsePacket(b)
+		if err != nil {
+			t.Errorf("failed to parse request: %v", err)
+			return nil
+		}
+		targets = append(targets, req.TargetIP)
+
+		switch req.TargetIP {
+		case netip.MustParseAddr("192.168.1.2"):
+			return []timedFrame{
+				// A malformed frame must not end the scan.
+				{b: malformedFrame(t)},
+				{b: replyFrame(t, mac2, req.TargetIP), after: 10 * time.Millisecond},
+				// Replies from outside the prefix are ignored.
+				{b: replyFrame(t, outside, netip.MustParseAddr("10.0.0.1"))},
+			}
+		case netip.MustParseAddr("192.168.1.5"):
+			return []timedFrame{{b: replyFrame(t, mac5, req.TargetIP)}}
+		}
+		return nil
+	})
+	c := testClient(t, p)
+
+	hosts, err := c.Scan(netip.MustParsePrefix("192.168.1.3/29"), ScanOptions{
+		Rate:    1000,
+		Timeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed to scan: %v", err)
+	}
+
+	want := map[netip.Addr]net.HardwareAddr{
+		netip.MustParseAddr("192.168.1.2"): mac2,
+		netip.MustParseAddr("192.168.1.5"): mac5,
+	}
+	if got := hosts; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected hosts:\n- want: %v\n-  got: %v", want, got)
+	}
+
+	var wantTargets []netip.Addr
+	for i := 1; i <= 6; i++ {
+		wantTargets = append(wantTargets, netip.AddrFrom4([4]byte{192, 168, 1, byte(i)}))
+	}
+	if got := targets; !reflect.DeepEqual(wantTargets, got) {
+		t.Fatalf("unexpected request targets:\n- want: %v\n-  got: %v", wantTargets, got)
+	}
+}
+
+func TestClientScanInvalidPrefix(t *testing.T) {
+	c := testClient(t, newReplyPacketConn(nil))
+
+	_, err := c.Scan(netip.MustParsePrefix("2001:db8::/64"), ScanOptions{})
+	if want, got := ErrInvalidIP, err; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+}
+
+func Test_isScanHost(t *testing.T) {
+	var tests = []struct {
+		prefix string
+		hosts  []string
+	}{
+		{
+			prefix: "192.168.1.0/30",
+			hosts:  []string{"192.168.1.1", "192.168.1.2"},
+		},
+		{
+			prefix: "192.168.1.0/31",
+			hosts:  []string{"192.168.1.0", "192.168.1.1"},
+		},
+		{
+			prefix: "192.168.1.1/32",
+			hosts:  []string{"192.168.1.1"},
+		},
+	}
+
+	for i, tt := range tests {
+		prefix := netip.MustParsePrefix(tt.prefix)
+
+		var got []string
+		for ip := prefix.Addr(); prefix.Contains(ip); ip = ip.Next() {
+			if isScanHost(prefix, ip) {
+				got = append(got, ip.String())
+			}
+		}
+
+		if want := tt.hosts; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected hosts:\n- want: %v\n-  got: %v",
+				i, tt.prefix, want, got)
+		}
+	}
+}
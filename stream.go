@@ -0,0 +1,74 @@
+package arp
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// streamPrefixLen is the length of the big endian length prefix which
+// precedes each Packet in a stream.
+const streamPrefixLen = 2
+
+// WriteTo implements io.WriterTo.  WriteTo writes p to w, preceded by a
+// 2 byte, big endian length prefix, so that a stream of Packets may be read
+// back using a Decoder.
+func (p *Packet) WriteTo(w io.Writer) (int64, error) {
+	pb, err := p.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+
+	b := make([]byte, streamPrefixLen+len(pb))
+	binary.BigEndian.PutUint16(b[0:2], uint16(len(pb)))
+	copy(b[streamPrefixLen:], pb)
+
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// A Decoder reads a stream of length prefixed Packets, such as those written
+// by Packet.WriteTo, from an input stream.
+type Decoder struct {
+	r   io.Reader
+	buf []byte
+}
+
+// NewDecoder creates a Decoder which reads Packets from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		r: r,
+	}
+}
+
+// Decode reads the next Packet from the stream.  Decode returns io.EOF when
+// no more Packets remain in the stream, or io.ErrUnexpectedEOF if the stream
+// ends partway through a Packet.
+func (d *Decoder) Decode() (*Packet, error) {
+	var prefix [streamPrefixLen]byte
+	if _, err := io.ReadFull(d.r, prefix[:]); err != nil {
+		return nil, err
+	}
+
+	n := int(binary.BigEndian.Uint16(prefix[:]))
+	if cap(d.buf) < n {
+		d.buf = make([]byte, n)
+	}
+	d.buf = d.buf[:n]
+
+	if _, err := io.ReadFull(d.r, d.buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	// UnmarshalBinary copies its input, so the returned Packet does not
+	// alias the Decoder's buffer.
+	p := new(Packet)
+	if err := p.UnmarshalBinary(d.buf); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
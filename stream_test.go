@@ -0,0 +1,62 @@
+package arp
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	var want []*Packet
+	for i := 1; i <= 3; i++ {
+		p, err := NewPacket(
+			OperationReply,
+			net.HardwareAddr{0xaa, 0xbb, 0xcc, 0xdd, 0xee, byte(i)},
+			netip.AddrFrom4([4]byte{192, 168, 1, byte(i)}),
+			net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+			netip.MustParseAddr("192.168.1.100"),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want = append(want, p)
+	}
+
+	var buf bytes.Buffer
+	for _, p := range want {
+		if _, err := p.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d := NewDecoder(&buf)
+
+	var got []*Packet
+	for {
+		p, err := d.Decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, p)
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected Packets:\n- want: %v\n-  got: %v", want, got)
+	}
+}
+
+func TestDecoderUnexpectedEOF(t *testing.T) {
+	d := NewDecoder(bytes.NewReader([]byte{0, 28, 0, 1}))
+
+	if _, err := d.Decode(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("unexpected error: %v != %v", io.ErrUnexpectedEOF, err)
+	}
+}
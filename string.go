@@ -4,14 +4,25 @@ package arp
 
 import "strconv"
 
-const _Operation_name = "OperationRequestOperationReply"
+const (
+	_Operation_name_0 = "OperationRequestOperationReplyOperationRARPRequestOperationRARPReply"
+	_Operation_name_1 = "OperationInARPRequestOperationInARPReply"
+)
 
-var _Operation_index = [...]uint8{0, 16, 30}
+var (
+	_Operation_index_0 = [...]uint8{0, 16, 30, 50, 68}
+	_Operation_index_1 = [...]uint8{0, 21, 40}
+)
 
 func (i Operation) String() string {
-	i -= 1
-	if i >= Operation(len(_Operation_index)-1) {
-		return "Operation(" + strconv.FormatInt(int64(i+1), 10) + ")"
+	switch {
+	case 1 <= i && i <= 4:
+		i -= 1
+		return _Operation_name_0[_Operation_index_0[i]:_Operation_index_0[i+1]]
+	case 8 <= i && i <= 9:
+		i -= 8
+		return _Operation_name_1[_Operation_index_1[i]:_Operation_index_1[i+1]]
+	default:
+		return "Operation(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _Operation_name[_Operation_index[i]:_Operation_index[i+1]]
 }
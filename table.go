@@ -0,0 +1,72 @@
+package arp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// An Entry is an entry in the operating system's ARP cache.
+type Entry struct {
+	// IP is the IPv4 address of the entry.
+	IP netip.Addr
+
+	// HardwareAddr is the hardware address associated with IP.  For
+	// incomplete entries, it is the all-zero hardware address.
+	HardwareAddr net.HardwareAddr
+
+	// Interface is the name of the network interface the entry belongs to.
+	Interface string
+
+	// Flags are the operating system's flags for the entry, such as
+	// ATF_COM (0x2) for complete entries on Linux.
+	Flags int
+}
+
+// parseTable parses an ARP cache in the format of Linux's /proc/net/arp.
+func parseTable(r io.Reader) ([]Entry, error) {
+	s := bufio.NewScanner(r)
+
+	// Skip the header line.
+	s.Scan()
+
+	var entries []Entry
+	for s.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(s.Text())
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("malformed ARP cache entry: %q", s.Text())
+		}
+
+		ip, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		flags, err := strconv.ParseInt(fields[2], 0, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		mac, err := net.ParseMAC(fields[3])
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, Entry{
+			IP:           ip,
+			HardwareAddr: mac,
+			Interface:    fields[5],
+			Flags:        int(flags),
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
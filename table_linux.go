@@ -0,0 +1,22 @@
+//go:build linux
+// +build linux
+
+package arp
+
+import "os"
+
+// Table returns the entries of the operating system's ARP cache, so callers
+// can avoid sending ARP requests for hardware addresses which are already
+// known.
+//
+// Table is only supported on Linux, where the ARP cache is read from
+// /proc/net/arp.
+func Table() ([]Entry, error) {
+	f, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseTable(f)
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package arp
+
+import "errors"
+
+// errTableUnsupported is returned by Table on platforms where the operating
+// system's ARP cache cannot be read.
+var errTableUnsupported = errors.New("reading the ARP cache is not supported on this platform")
+
+// Table returns the entries of the operating system's ARP cache.
+//
+// Table is only supported on Linux.
+func Table() ([]Entry, error) {
+	return nil, errTableUnsupported
+}
@@ -0,0 +1,79 @@
+package arp
+
+import (
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseTable(t *testing.T) {
+	tests := []struct {
+		desc    string
+		s       string
+		entries []Entry
+		ok      bool
+	}{
+		{
+			desc: "empty",
+			s:    "IP address       HW type     Flags       HW address            Mask     Device\n",
+			ok:   true,
+		},
+		{
+			desc: "OK",
+			s: `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2         00:12:7f:eb:6b:40     *        eth0
+192.168.1.20     0x1         0x0         00:00:00:00:00:00     *        eth0
+10.0.0.1         0x1         0x6         de:ad:be:ef:de:ad     *        eth1
+`,
+			entries: []Entry{
+				{
+					IP:           netip.MustParseAddr("192.168.1.1"),
+					HardwareAddr: net.HardwareAddr{0x00, 0x12, 0x7f, 0xeb, 0x6b, 0x40},
+					Interface:    "eth0",
+					Flags:        0x2,
+				},
+				{
+					IP:           netip.MustParseAddr("192.168.1.20"),
+					HardwareAddr: net.HardwareAddr{0, 0, 0, 0, 0, 0},
+					Interface:    "eth0",
+				},
+				{
+					IP:           netip.MustParseAddr("10.0.0.1"),
+					HardwareAddr: net.HardwareAddr{0xde, 0xad, 0xbe, 0xef, 0xde, 0xad},
+					Interface:    "eth1",
+					Flags:        0x6,
+				},
+			},
+			ok: true,
+		},
+		{
+			desc: "malformed entry",
+			s: `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1.1      0x1         0x2
+`,
+		},
+		{
+			desc: "bad IP address",
+			s: `IP address       HW type     Flags       HW address            Mask     Device
+192.168.1        0x1         0x2         00:12:7f:eb:6b:40     *        eth0
+`,
+		},
+	}
+
+	for i, tt := range tests {
+		entries, err := parseTable(strings.NewReader(tt.s))
+		if err != nil && tt.ok {
+			t.Fatalf("[%02d] test %q, unexpected error: %v", i, tt.desc, err)
+		}
+		if err == nil && !tt.ok {
+			t.Fatalf("[%02d] test %q, expected an error, but none occurred", i, tt.desc)
+		}
+
+		if want, got := tt.entries, entries; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected entries:\n- want: %v\n-  got: %v",
+				i, tt.desc, want, got)
+		}
+	}
+}
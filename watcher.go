@@ -0,0 +1,143 @@
+package arp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/netip"
+)
+
+//go:generate stringer -output=eventtype_string.go -type=EventType -trimprefix=Event
+
+// An EventType is the type of an Event reported by a Watcher.
+type EventType int
+
+// EventType constants which indicate how an IPv4 to hardware address
+// binding changed.
+const (
+	// EventAdded indicates a binding for a previously unseen IPv4 address.
+	EventAdded EventType = iota + 1
+
+	// EventUpdated indicates that a machine announced, using gratuitous
+	// ARP, that an IPv4 address now belongs to a different hardware
+	// address.
+	EventUpdated
+
+	// EventConflict indicates that a different hardware address claimed
+	// an IPv4 address without announcing it, which may indicate ARP
+	// spoofing or a duplicate address.
+	EventConflict
+)
+
+// An Event is a change to an IPv4 to hardware address binding observed by a
+// Watcher.
+type Event struct {
+	// Type is the type of the Event.
+	Type EventType
+
+	// IP is the IPv4 address of the binding.
+	IP netip.Addr
+
+	// HardwareAddr is the hardware address which claimed IP.
+	HardwareAddr net.HardwareAddr
+
+	// Previous is the hardware address previously bound to IP, for
+	// EventUpdated and EventConflict.
+	Previous net.HardwareAddr
+}
+
+// A Watcher passively tracks the IPv4 to hardware address bindings claimed
+// by ARP packets on a Client's interface, and reports changes to them as
+// Events.
+type Watcher struct {
+	c        *Client
+	events   chan Event
+	bindings map[netip.Addr]net.HardwareAddr
+}
+
+// NewWatcher creates a Watcher which reads ARP packets using c.  Run must be
+// called to begin watching.
+func NewWatcher(c *Client) *Watcher {
+	return &Watcher{
+		c:        c,
+		events:   make(chan Event, 16),
+		bindings: make(map[netip.Addr]net.HardwareAddr),
+	}
+}
+
+// Events returns a channel of Events observed by the Watcher.  The channel
+// is closed when Run returns.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Run watches ARP packets until ctx is canceled or reading from the Client's
+// socket fails, sending an Event whenever a binding is added or changes.
+// Frames which cannot be parsed as ARP packets are skipped.  Run blocks while
+// the Events channel is full, so callers must receive Events concurrently.
+//
+// Once a binding is known, a different hardware address claiming the same
+// IPv4 address is reported as EventUpdated only if it was announced with
+// gratuitous ARP, in which case the binding is replaced.  Otherwise it is
+// reported as EventConflict and the known binding is kept, so repeated
+// claims are reported each time.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	for {
+		p, _, err := w.c.monitorContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		e, ok := w.observe(p)
+		if !ok {
+			continue
+		}
+
+		select {
+		case w.events <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// observe updates the bindings of w using the sender addresses of p, and
+// returns an Event if a binding was added or changed.
+func (w *Watcher) observe(p *Packet) (Event, bool) {
+	ip, mac := p.SenderIP, p.SenderHardwareAddr
+
+	// Probes do not claim an address.
+	if !ip.IsValid() || ip.IsUnspecified() {
+		return Event{}, false
+	}
+
+	prev, ok := w.bindings[ip]
+	switch {
+	case !ok:
+		w.bindings[ip] = cloneHardwareAddr(mac)
+		return Event{
+			Type:         EventAdded,
+			IP:           ip,
+			HardwareAddr: cloneHardwareAddr(mac),
+		}, true
+	case bytes.Equal(prev, mac):
+		return Event{}, false
+	case p.TargetIP == ip:
+		w.bindings[ip] = cloneHardwareAddr(mac)
+		return Event{
+			Type:         EventUpdated,
+			IP:           ip,
+			HardwareAddr: cloneHardwareAddr(mac),
+			Previous:     prev,
+		}, true
+	default:
+		return Event{
+			Type:         EventConflict,
+			IP:           ip,
+			HardwareAddr: cloneHardwareAddr(mac),
+			Previous:     prev,
+		}, true
+	}
+}
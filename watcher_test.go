@@ -0,0 +1,135 @@
+package arp
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/ethernet"
+)
+
+func TestWatcherObserve(t *testing.T) {
+	var (
+		ip    = netip.MustParseAddr("192.168.1.10")
+		other = netip.MustParseAddr("192.168.1.20")
+
+		macA = net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+		macB = net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}
+		macC = net.HardwareAddr{0xcc, 0xcc, 0xcc, 0xcc, 0xcc, 0xcc}
+	)
+
+	newPacket := func(mac net.HardwareAddr, sender, target netip.Addr) *Packet {
+		p, err := NewPacket(OperationRequest, mac, sender, ethernet.Broadcast, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		desc string
+		p    *Packet
+		e    *Event
+	}{
+		{
+			desc: "probe ignored",
+			p:    newPacket(macA, netip.IPv4Unspecified(), ip),
+		},
+		{
+			desc: "new binding added",
+			p:    newPacket(macA, ip, other),
+			e:    &Event{Type: EventAdded, IP: ip, HardwareAddr: macA},
+		},
+		{
+			desc: "same binding ignored",
+			p:    newPacket(macA, ip, other),
+		},
+		{
+			desc: "different MAC without announcement conflicts",
+			p:    newPacket(macB, ip, other),
+			e:    &Event{Type: EventConflict, IP: ip, HardwareAddr: macB, Previous: macA},
+		},
+		{
+			desc: "original binding kept after conflict",
+			p:    newPacket(macA, ip, other),
+		},
+		{
+			desc: "gratuitous ARP updates binding",
+			p:    newPacket(macC, ip, ip),
+			e:    &Event{Type: EventUpdated, IP: ip, HardwareAddr: macC, Previous: macA},
+		},
+		{
+			desc: "previous MAC now conflicts",
+			p:    newPacket(macA, ip, other),
+			e:    &Event{Type: EventConflict, IP: ip, HardwareAddr: macA, Previous: macC},
+		},
+	}
+
+	w := NewWatcher(nil)
+	for i, tt := range tests {
+		e, ok := w.observe(tt.p)
+		if want, got := tt.e != nil, ok; want != got {
+			t.Fatalf("[%02d] test %q, unexpected event presence: %v != %v",
+				i, tt.desc, want, got)
+		}
+		if !ok {
+			continue
+		}
+
+		if want, got := *tt.e, e; !reflect.DeepEqual(want, got) {
+			t.Fatalf("[%02d] test %q, unexpected Event:\n- want: %+v\n-  got: %+v",
+				i, tt.desc, want, got)
+		}
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	ip := netip.MustParseAddr("192.168.1.10")
+	macA := net.HardwareAddr{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+	macB := net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}
+
+	first, err := NewPacket(OperationRequest, macA, ip, ethernet.Broadcast,
+		netip.MustParseAddr("192.168.1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spoofed, err := NewPacket(OperationReply, macB, ip, macA,
+		netip.MustParseAddr("192.168.1.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A malformed frame must not end watching.
+	p := newReplyPacketConn(nil)
+	p.frames <- packetFrame(t, first, ethernet.Broadcast)
+	p.frames <- malformedFrame(t)
+	p.frames <- packetFrame(t, spoofed, macA)
+
+	w := NewWatcher(testClient(t, p))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- w.Run(ctx)
+	}()
+
+	var got []EventType
+	for e := range w.Events() {
+		got = append(got, e.Type)
+		if len(got) == 2 {
+			cancel()
+		}
+	}
+
+	if want, got := context.Canceled, <-errC; want != got {
+		t.Fatalf("unexpected error: %v != %v", want, got)
+	}
+	if want := []EventType{EventAdded, EventConflict}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("unexpected event types:\n- want: %v\n-  got: %v", want, got)
+	}
+}